@@ -0,0 +1,155 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words bytes context encoding json http slices strings time
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Advisory is a security advisory of a composer package, as published by Packagist.
+type Advisory struct {
+	ID               string    `json:"id"`                   // Packagist advisory ID, e.g. "PKSA-n8hw-tywm-xrh7"
+	Package          string    `json:"package"`              // composer package name
+	Title            string    `json:"title"`                // summary of the vulnerability
+	CVE              string    `json:"cve,omitempty"`        // CVE identifier, if any
+	AffectedVersions string    `json:"affected_versions"`    // constraint of affected versions, e.g. ">=1.0.0,<1.2.3|>=2.0.0,<2.0.1"
+	Link             string    `json:"link,omitempty"`       // page describing the vulnerability
+	Severity         string    `json:"severity,omitempty"`   // severity, e.g. "high", if known
+	ReportedAt       time.Time `json:"reported_at,omitzero"` // date the advisory was published, if known
+}
+
+// Affects reports whether the given version is affected by the advisory.
+// Versions that cannot be parsed, and advisories with invalid affected versions, are never affected.
+func (a Advisory) Affects(version string) bool {
+	return a.affects(ParseVersion(strings.TrimPrefix(version, "v")))
+}
+
+func (a Advisory) affects(v Version) bool {
+	if v.Major < 0 || v.Minor == WildcardSegment {
+		return false
+	}
+	c, err := ParseConstraint(a.AffectedVersions)
+	if err != nil {
+		return false
+	}
+	return c.Matches(v)
+}
+
+// advisoryBatchSize is the maximum number of packages requested from the advisories API at once.
+const advisoryBatchSize = 50
+
+// FetchAdvisories fetches the security advisories of the given packages from the Packagist API.
+// The result maps each package with advisories to its advisories, ordered like returned by Packagist.
+// It returns no advisories if the PackagistAPIURL of c is empty.
+func (c *Client) FetchAdvisories(ctx context.Context, pkgs []string) (map[string][]Advisory, error) {
+	advisories := make(map[string][]Advisory)
+	if c.PackagistAPIURL == "" {
+		return advisories, nil
+	}
+
+	for batch := range slices.Chunk(pkgs, advisoryBatchSize) {
+		query := url.Values{"packages[]": batch}
+		found, err := fetchResponse(ctx, c, c.PackagistAPIURL+"/api/security-advisories/?"+query.Encode(), decodeAdvisories)
+		if err != nil {
+			return nil, fmt.Errorf("security advisories: %w", err)
+		}
+		for pkg, list := range found {
+			advisories[pkg] = append(advisories[pkg], list...)
+		}
+	}
+	return advisories, nil
+}
+
+// packagistAdvisory represents a single advisory from the Packagist API.
+type packagistAdvisory struct {
+	AdvisoryID       string `json:"advisoryId"`
+	Title            string `json:"title"`
+	CVE              string `json:"cve"`
+	AffectedVersions string `json:"affectedVersions"`
+	Link             string `json:"link"`
+	Severity         string `json:"severity"`
+	ReportedAt       string `json:"reportedAt"` // e.g. "2024-03-06 13:00:00"
+}
+
+// decodeAdvisories decodes a response of the Packagist advisories API.
+func decodeAdvisories(body io.Reader) (map[string][]Advisory, error) {
+	var result struct {
+		Advisories json.RawMessage `json:"advisories"`
+	}
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode JSON: %w", err)
+	}
+
+	// packages without advisories result in an empty list instead of an object
+	var found map[string][]packagistAdvisory
+	if bytes.HasPrefix(bytes.TrimSpace(result.Advisories), []byte("{")) {
+		if err := json.Unmarshal(result.Advisories, &found); err != nil {
+			return nil, fmt.Errorf("decode JSON: %w", err)
+		}
+	}
+
+	advisories := make(map[string][]Advisory, len(found))
+	for pkg, list := range found {
+		for _, a := range list {
+			advisory := Advisory{
+				ID:               a.AdvisoryID,
+				Package:          pkg,
+				Title:            a.Title,
+				CVE:              a.CVE,
+				AffectedVersions: a.AffectedVersions,
+				Link:             a.Link,
+				Severity:         a.Severity,
+			}
+			for _, layout := range []string{time.DateTime, time.RFC3339} {
+				if date, err := time.Parse(layout, a.ReportedAt); err == nil {
+					advisory.ReportedAt = date
+					break
+				}
+			}
+			advisories[pkg] = append(advisories[pkg], advisory)
+		}
+	}
+	return advisories, nil
+}
+
+// MarkAdvisories sets the Advisories of each release to those of advisories affecting it.
+func MarkAdvisories(releases []Release, advisories []Advisory) {
+	for i := range releases {
+		releases[i].Advisories = nil
+		for _, advisory := range advisories {
+			if advisory.Affects(releases[i].Version) {
+				releases[i].Advisories = append(releases[i].Advisories, advisory)
+			}
+		}
+	}
+}
+
+// CurrentAdvisories returns the advisories affecting the current version of a package.
+// This is the installed version, if given, and the lower bound of the current constraint otherwise,
+// i.e. the oldest version the constraint allows installing.
+func CurrentAdvisories(advisories []Advisory, current, installed string) []Advisory {
+	version := ParseVersion(strings.TrimPrefix(installed, "v"))
+	if installed == "" {
+		c, err := ParseConstraint(current)
+		if err != nil {
+			return nil
+		}
+		version = c.base()
+	}
+
+	var affecting []Advisory
+	for _, advisory := range advisories {
+		if advisory.affects(version) {
+			affecting = append(affecting, advisory)
+		}
+	}
+	return affecting
+}
@@ -0,0 +1,149 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest strconv sync atomic testing github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+// sampleAdvisoriesJSON is a Packagist security advisories response for drush/drush.
+const sampleAdvisoriesJSON = `{"advisories": {"drush/drush": [
+	{
+		"advisoryId": "PKSA-1111-2222-3333",
+		"packageName": "drush/drush",
+		"title": "Arbitrary code execution in sql:sync",
+		"cve": "CVE-2024-12345",
+		"affectedVersions": ">=12.0.0,<12.5.6|>=13.0.0,<13.0.1",
+		"link": "https://example.com/advisories/CVE-2024-12345",
+		"severity": "high",
+		"reportedAt": "2024-11-19 10:00:00"
+	}
+]}}`
+
+func TestFetchAdvisories(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/security-advisories/" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		body := `{"advisories": []}`
+		for _, pkg := range r.URL.Query()["packages[]"] {
+			if pkg == "drush/drush" {
+				body = sampleAdvisoriesJSON
+			}
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.PackagistAPIURL = server.URL
+
+	pkgs := []string{"drush/drush"}
+	for i := range 60 {
+		pkgs = append(pkgs, "acme/lib"+strconv.Itoa(i))
+	}
+	advisories, err := client.FetchAdvisories(t.Context(), pkgs)
+	if err != nil {
+		t.Fatalf("FetchAdvisories returned error: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected the packages to be requested in 2 batches, got %d requests", got)
+	}
+	if len(advisories) != 1 || len(advisories["drush/drush"]) != 1 {
+		t.Fatalf("expected a single advisory for drush/drush, got %+v", advisories)
+	}
+
+	advisory := advisories["drush/drush"][0]
+	if advisory.ID != "PKSA-1111-2222-3333" || advisory.Package != "drush/drush" || advisory.CVE != "CVE-2024-12345" || advisory.Severity != "high" {
+		t.Errorf("unexpected advisory: %+v", advisory)
+	}
+	if advisory.ReportedAt.Format("2006-01-02") != "2024-11-19" {
+		t.Errorf("expected reported at 2024-11-19, got %v", advisory.ReportedAt)
+	}
+}
+
+func TestFetchAdvisories_Disabled(t *testing.T) {
+	t.Parallel()
+	client := drupalupdate.NewClient()
+	client.PackagistAPIURL = ""
+
+	advisories, err := client.FetchAdvisories(t.Context(), []string{"drush/drush"})
+	if err != nil || len(advisories) != 0 {
+		t.Errorf("expected no advisories without an API URL, got %v, %v", advisories, err)
+	}
+}
+
+func TestAdvisory_Affects(t *testing.T) {
+	t.Parallel()
+	advisory := drupalupdate.Advisory{AffectedVersions: ">=12.0.0,<12.5.6|>=13.0.0,<13.0.1"}
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"12.0.0", true},
+		{"12.5.5", true},
+		{"v12.5.5", true},
+		{"12.5.6", false},
+		{"13.0.0", true},
+		{"13.0.1", false},
+		{"11.9.0", false},
+		{"dev-main", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := advisory.Affects(tt.version); got != tt.want {
+			t.Errorf("Affects(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMarkAdvisories(t *testing.T) {
+	t.Parallel()
+	advisories := []drupalupdate.Advisory{
+		{ID: "A", AffectedVersions: "<13.0.1"},
+		{ID: "B", AffectedVersions: ">=12.0,<12.5.6"},
+	}
+	releases := []drupalupdate.Release{{Version: "13.0.1"}, {Version: "13.0.0"}, {Version: "12.5.5"}}
+
+	drupalupdate.MarkAdvisories(releases, advisories)
+
+	want := []int{0, 1, 2}
+	for i, count := range want {
+		if len(releases[i].Advisories) != count {
+			t.Errorf("release %s: expected %d advisories, got %+v", releases[i].Version, count, releases[i].Advisories)
+		}
+	}
+}
+
+func TestCurrentAdvisories(t *testing.T) {
+	t.Parallel()
+	advisories := []drupalupdate.Advisory{{ID: "A", AffectedVersions: ">=12.0.0,<12.5.6"}}
+	tests := []struct {
+		current, installed string
+		want               int
+	}{
+		{"^12.4", "", 1},
+		{"^12.5.6", "", 0},
+		{"^12.4", "12.5.6", 0},
+		{"^12.4", "12.5.0", 1},
+		{"", "", 0},
+		{"dev-main", "", 0},
+	}
+	for _, tt := range tests {
+		if got := drupalupdate.CurrentAdvisories(advisories, tt.current, tt.installed); len(got) != tt.want {
+			t.Errorf("CurrentAdvisories(%q, %q) = %+v, want %d advisories", tt.current, tt.installed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words encoding json errors maps http path filepath strings
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Auth holds credentials for composer repositories per host, in the format of Composer's auth.json.
+// See https://getcomposer.org/doc/articles/authentication-for-private-packages.md.
+type Auth struct {
+	HTTPBasic   map[string]HTTPBasicAuth `json:"http-basic,omitempty"`   // host -> username and password
+	Bearer      map[string]string        `json:"bearer,omitempty"`       // host -> bearer token
+	GitLabToken map[string]GitLabToken   `json:"gitlab-token,omitempty"` // host -> GitLab token
+}
+
+// HTTPBasicAuth are the credentials of a host using HTTP basic authentication.
+type HTTPBasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// GitLabToken is a GitLab token of a host.
+// Without a username, the token is a private or personal access token sent in the "PRIVATE-TOKEN" header.
+// With a username, e.g. for deploy tokens, it is sent using HTTP basic authentication.
+type GitLabToken struct {
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler for GitLabToken.
+// Tokens are given either as a string, or as an object with a username and a token.
+func (t *GitLabToken) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Token); err == nil {
+		t.Username = ""
+		return nil
+	}
+	type gitLabToken GitLabToken // prevent recursion
+	var raw gitLabToken
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal gitlab token: %w", err)
+	}
+	*t = GitLabToken(raw)
+	return nil
+}
+
+// ParseAuth parses the contents of an auth.json file, or of the COMPOSER_AUTH environment variable.
+func ParseAuth(data []byte) (Auth, error) {
+	var auth Auth
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return Auth{}, fmt.Errorf("parse auth.json: %w", err)
+	}
+	return auth, nil
+}
+
+// Merge returns the credentials of a and other combined.
+// The credentials of other take precedence for hosts present in both.
+func (a Auth) Merge(other Auth) Auth {
+	return Auth{
+		HTTPBasic:   mergeCredentials(a.HTTPBasic, other.HTTPBasic),
+		Bearer:      mergeCredentials(a.Bearer, other.Bearer),
+		GitLabToken: mergeCredentials(a.GitLabToken, other.GitLabToken),
+	}
+}
+
+// mergeCredentials returns a new map with the entries of base and override, where override takes precedence.
+func mergeCredentials[T any](base, override map[string]T) map[string]T {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]T, len(base)+len(override))
+	maps.Copy(merged, base)
+	maps.Copy(merged, override)
+	return merged
+}
+
+// authorize adds the credentials for the host of req, if any.
+// Hosts are looked up with their port first, and by their name only otherwise.
+// When a host has several kinds of credentials, a bearer token takes precedence over a GitLab token,
+// which takes precedence over HTTP basic authentication.
+func (a Auth) authorize(req *http.Request) {
+	for _, host := range []string{req.URL.Host, req.URL.Hostname()} {
+		if token, ok := a.Bearer[host]; ok {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return
+		}
+		if token, ok := a.GitLabToken[host]; ok {
+			if token.Username == "" {
+				req.Header.Set("PRIVATE-TOKEN", token.Token)
+			} else {
+				req.SetBasicAuth(token.Username, token.Token)
+			}
+			return
+		}
+		if basic, ok := a.HTTPBasic[host]; ok {
+			req.SetBasicAuth(basic.Username, basic.Password)
+			return
+		}
+	}
+}
+
+// maxRedirects is the number of redirects followed when fetching, like [http.Client] does by default.
+const maxRedirects = 10
+
+var errTooManyRedirects = errors.New("too many redirects")
+
+// checkRedirect is the CheckRedirect function of the HTTP client of [NewClient].
+// Like [http.Client] does for the Authorization header, it drops the "PRIVATE-TOKEN" header
+// of GitLab tokens on redirects to another host, so that the token is not leaked to it.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", errTooManyRedirects, maxRedirects)
+	}
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("PRIVATE-TOKEN")
+	}
+	return nil
+}
+
+// =============================================================================
+// Loading
+// =============================================================================
+
+var errComposerHome = errors.New("cannot determine the composer home directory")
+
+// ComposerHome returns the home directory of Composer, where its global auth.json is stored.
+// It is the COMPOSER_HOME environment variable if set, "~/.composer" if it exists,
+// and the "composer" directory in the user configuration directory otherwise.
+func ComposerHome() (string, error) {
+	if home := os.Getenv("COMPOSER_HOME"); home != "" {
+		return home, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		legacy := filepath.Join(home, ".composer")
+		if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+			return legacy, nil
+		}
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errComposerHome, err)
+	}
+	return filepath.Join(config, "composer"), nil
+}
+
+// LoadAuth loads credentials like Composer does:
+// from the auth.json in [ComposerHome], the auth.json in the project directory dir,
+// and the COMPOSER_AUTH environment variable, where later sources take precedence.
+// Missing files are skipped, and an empty dir skips the project auth.json.
+func LoadAuth(dir string) (Auth, error) {
+	var paths []string
+	if home, err := ComposerHome(); err == nil {
+		paths = append(paths, filepath.Join(home, "auth.json"))
+	}
+	if dir != "" {
+		paths = append(paths, filepath.Join(dir, "auth.json"))
+	}
+
+	var auth Auth
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Auth{}, fmt.Errorf("open %s: %w", path, err)
+		}
+		found, err := ParseAuth(data)
+		if err != nil {
+			return Auth{}, fmt.Errorf("read %s: %w", path, err)
+		}
+		auth = auth.Merge(found)
+	}
+
+	if env := strings.TrimSpace(os.Getenv("COMPOSER_AUTH")); env != "" {
+		found, err := ParseAuth([]byte(env))
+		if err != nil {
+			return Auth{}, fmt.Errorf("read COMPOSER_AUTH: %w", err)
+		}
+		auth = auth.Merge(found)
+	}
+	return auth, nil
+}
@@ -0,0 +1,280 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest path filepath strings sync atomic testing github composer drupal update drupalupdate gitlab
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestParseAuth(t *testing.T) {
+	t.Parallel()
+	auth, err := drupalupdate.ParseAuth([]byte(`{
+		"http-basic": {"satis.example.com": {"username": "alice", "password": "secret"}},
+		"bearer": {"api.example.com": "token"},
+		"gitlab-token": {
+			"gitlab.com": "glpat-123",
+			"gitlab.example.com": {"username": "deploy", "token": "gldt-456"}
+		},
+		"github-oauth": {"github.com": "ignored"}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseAuth returned error: %v", err)
+	}
+
+	if auth.HTTPBasic["satis.example.com"] != (drupalupdate.HTTPBasicAuth{Username: "alice", Password: "secret"}) {
+		t.Errorf("unexpected http-basic credentials: %+v", auth.HTTPBasic)
+	}
+	if auth.Bearer["api.example.com"] != "token" {
+		t.Errorf("unexpected bearer credentials: %+v", auth.Bearer)
+	}
+	if auth.GitLabToken["gitlab.com"] != (drupalupdate.GitLabToken{Token: "glpat-123"}) {
+		t.Errorf("unexpected gitlab token: %+v", auth.GitLabToken["gitlab.com"])
+	}
+	if auth.GitLabToken["gitlab.example.com"] != (drupalupdate.GitLabToken{Username: "deploy", Token: "gldt-456"}) {
+		t.Errorf("unexpected gitlab token: %+v", auth.GitLabToken["gitlab.example.com"])
+	}
+}
+
+func TestParseAuth_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{`not json`, `{"gitlab-token": {"gitlab.com": 42}}`} {
+		if _, err := drupalupdate.ParseAuth([]byte(input)); err == nil {
+			t.Errorf("ParseAuth(%s) expected error, got nil", input)
+		}
+	}
+}
+
+func TestAuth_Merge(t *testing.T) {
+	t.Parallel()
+	global := drupalupdate.Auth{Bearer: map[string]string{"a.example.com": "global", "b.example.com": "global"}}
+	project := drupalupdate.Auth{Bearer: map[string]string{"b.example.com": "project"}}
+
+	merged := global.Merge(project)
+	if merged.Bearer["a.example.com"] != "global" || merged.Bearer["b.example.com"] != "project" {
+		t.Errorf("unexpected merged credentials: %+v", merged.Bearer)
+	}
+	if global.Bearer["b.example.com"] != "global" {
+		t.Error("Merge must not modify its receiver")
+	}
+}
+
+func TestLoadAuth(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	t.Setenv("COMPOSER_HOME", home)
+	t.Setenv("COMPOSER_AUTH", `{"bearer": {"env.example.com": "env"}}`)
+
+	files := map[string]string{
+		filepath.Join(home, "auth.json"):    `{"bearer": {"global.example.com": "global", "project.example.com": "global"}}`,
+		filepath.Join(project, "auth.json"): `{"bearer": {"project.example.com": "project", "env.example.com": "project"}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auth, err := drupalupdate.LoadAuth(project)
+	if err != nil {
+		t.Fatalf("LoadAuth returned error: %v", err)
+	}
+	want := map[string]string{"global.example.com": "global", "project.example.com": "project", "env.example.com": "env"}
+	for host, token := range want {
+		if auth.Bearer[host] != token {
+			t.Errorf("expected token %q for %s, got %q", token, host, auth.Bearer[host])
+		}
+	}
+}
+
+func TestLoadAuth_Missing(t *testing.T) {
+	t.Setenv("COMPOSER_HOME", t.TempDir())
+	t.Setenv("COMPOSER_AUTH", "")
+
+	auth, err := drupalupdate.LoadAuth(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadAuth returned error: %v", err)
+	}
+	if len(auth.HTTPBasic) != 0 || len(auth.Bearer) != 0 || len(auth.GitLabToken) != 0 {
+		t.Errorf("expected no credentials, got %+v", auth)
+	}
+}
+
+func TestFetchReleases_Auth(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		credential func(host string) drupalupdate.Auth
+		authorized func(r *http.Request) bool
+	}{
+		{
+			name: "http-basic",
+			credential: func(host string) drupalupdate.Auth {
+				return drupalupdate.Auth{HTTPBasic: map[string]drupalupdate.HTTPBasicAuth{host: {Username: "alice", Password: "secret"}}}
+			},
+			authorized: func(r *http.Request) bool {
+				username, password, ok := r.BasicAuth()
+				return ok && username == "alice" && password == "secret"
+			},
+		},
+		{
+			name: "bearer",
+			credential: func(host string) drupalupdate.Auth {
+				return drupalupdate.Auth{Bearer: map[string]string{host: "token"}}
+			},
+			authorized: func(r *http.Request) bool {
+				return r.Header.Get("Authorization") == "Bearer token"
+			},
+		},
+		{
+			name: "gitlab-token",
+			credential: func(host string) drupalupdate.Auth {
+				return drupalupdate.Auth{GitLabToken: map[string]drupalupdate.GitLabToken{host: {Token: "glpat-123"}}}
+			},
+			authorized: func(r *http.Request) bool {
+				return r.Header.Get("PRIVATE-TOKEN") == "glpat-123"
+			},
+		},
+		{
+			name: "gitlab-token with username",
+			credential: func(host string) drupalupdate.Auth {
+				return drupalupdate.Auth{GitLabToken: map[string]drupalupdate.GitLabToken{host: {Username: "deploy", Token: "gldt-456"}}}
+			},
+			authorized: func(r *http.Request) bool {
+				username, password, ok := r.BasicAuth()
+				return ok && username == "deploy" && password == "gldt-456"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			satis := newSatisServer(t)
+			t.Cleanup(satis.Close)
+			private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.authorized(r) {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				satis.Config.Handler.ServeHTTP(w, r)
+			}))
+			t.Cleanup(private.Close)
+
+			u, err := url.Parse(private.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := drupalupdate.NewClient()
+			client.PackagistBaseURL = ""
+			client.Repositories = drupalupdate.Repositories{
+				List: []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: private.URL}},
+			}
+
+			if _, err := client.FetchReleases(t.Context(), "acme/lib"); err == nil {
+				t.Fatal("expected an error without credentials")
+			}
+
+			releases, err := client.WithAuth(tt.credential(u.Host)).FetchReleases(t.Context(), "acme/lib")
+			if err != nil {
+				t.Fatalf("FetchReleases returned error: %v", err)
+			}
+			if len(releases) == 0 {
+				t.Error("expected releases")
+			}
+		})
+	}
+}
+
+func TestFetchReleases_Auth_Redirect(t *testing.T) {
+	t.Parallel()
+	satis := newSatisServer(t)
+	defer satis.Close()
+
+	// the metadata is served from another host, which must not receive the token
+	var leaked atomic.Bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "" {
+			leaked.Store(true)
+		}
+		satis.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer other.Close()
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-123" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/packages.json" {
+			satis.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		http.Redirect(w, r, strings.Replace(other.URL, "127.0.0.1", "localhost", 1)+r.URL.Path, http.StatusFound)
+	}))
+	defer private.Close()
+
+	u, err := url.Parse(private.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := drupalupdate.NewClient().WithAuth(drupalupdate.Auth{GitLabToken: map[string]drupalupdate.GitLabToken{u.Host: {Token: "glpat-123"}}})
+	client.PackagistBaseURL = ""
+	client.Repositories = drupalupdate.Repositories{
+		List: []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: private.URL}},
+	}
+
+	releases, err := client.FetchReleases(t.Context(), "acme/lib")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) == 0 {
+		t.Error("expected releases")
+	}
+	if leaked.Load() {
+		t.Error("expected the token not to be sent to another host after a redirect")
+	}
+}
+
+func TestFetchReleases_Auth_RepositoryPolicy(t *testing.T) {
+	t.Parallel()
+	satis := newSatisServer(t)
+	defer satis.Close()
+
+	var leaked atomic.Bool
+	repo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			leaked.Store(true)
+		}
+		satis.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer repo.Close()
+
+	u, err := url.Parse(repo.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := drupalupdate.NewClient().
+		WithAuth(drupalupdate.Auth{Bearer: map[string]string{u.Host: "token"}}).
+		WithRepositoryPolicy(drupalupdate.NewRepositoryPolicy([]string{u.Hostname()}, true))
+	client.PackagistBaseURL = ""
+	client.Repositories = drupalupdate.Repositories{
+		List: []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: repo.URL}},
+	}
+
+	if _, err := client.FetchReleases(t.Context(), "acme/lib"); err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if leaked.Load() {
+		t.Error("expected no credentials to be sent to repositories restricted by a policy")
+	}
+}
@@ -0,0 +1,128 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words context slices sync
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// DefaultWorkers is the default maximum number of concurrent fetches of [Client.FetchAll].
+const DefaultWorkers = 8
+
+// PackageResult is the result of fetching the releases of a single package using [Client.FetchAll].
+type PackageResult struct {
+	Package  Package
+	Releases PackageReleases
+	Err      error // error fetching the releases, if any
+}
+
+// ProgressFunc is called by [Client.FetchAll] whenever the releases of a package have been fetched,
+// with the number of packages done so far and the total number of packages.
+type ProgressFunc func(done, total int, pkg Package)
+
+// ForPackage returns a copy of c using the stability settings and pin strategy for pkg.
+// A stability flag of the current constraint of pkg overrides the minimum stability,
+// the pin strategy given for pkg in Pins overrides the one of c,
+// and [PinKeep] is resolved to the style of the current constraint.
+func (c *Client) ForPackage(pkg Package) *Client {
+	strategy, ok := c.Pins[pkg.Name]
+	if !ok {
+		strategy = c.PinStrategy
+	}
+	return c.
+		WithStability(c.Stability.ForConstraint(pkg.Version)).
+		WithPinStrategy(strategy.ForConstraint(pkg.Version))
+}
+
+// fetchKey identifies the fetches of [Client.FetchAll] that result in the same releases.
+type fetchKey struct {
+	name      string
+	stability StabilitySettings
+	strategy  PinStrategy
+}
+
+// batchFetch is a single fetch of [Client.FetchAll], shared by the packages at indices.
+type batchFetch struct {
+	client  *Client
+	name    string
+	indices []int
+}
+
+// FetchAll fetches the releases of all pkgs like [Client.FetchPackageReleases], using the client returned by [Client.ForPackage].
+// At most Workers packages of c are fetched concurrently, and the Progress function of c is called after each package.
+//
+// Packages resulting in the same fetch are only fetched once.
+// In particular, all Drupal core packages (drupal/core, drupal/core-recommended, etc.) share the fetch of the "drupal" project,
+// and the packages.json file of each composer repository is fetched once.
+//
+// The results are ordered like pkgs. Failing to fetch a package is reported in its result,
+// and does not affect the other packages.
+func (c *Client) FetchAll(ctx context.Context, pkgs []Package) []PackageResult {
+	// packages from the same composer repository share the fetch of its packages.json
+	batch := *c
+	batch.indexes = &repositoryIndexes{entries: make(map[repositoryIndexKey]*repositoryIndexEntry)}
+	c = &batch
+
+	results := make([]PackageResult, len(pkgs))
+	var fetches []*batchFetch
+	byKey := make(map[fetchKey]*batchFetch)
+	for i, pkg := range pkgs {
+		results[i].Package = pkg
+
+		client := c.ForPackage(pkg)
+		key := fetchKey{name: pkg.Name, stability: client.Stability, strategy: client.PinStrategy}
+		if name, ok := drupalModuleName(pkg.Name); ok && isCorePackage(name) {
+			if _, mapped := c.Projects[pkg.Name]; !mapped {
+				key.name = "drupal/core"
+			}
+		}
+		if fetch, ok := byKey[key]; ok {
+			fetch.indices = append(fetch.indices, i)
+			continue
+		}
+		fetch := &batchFetch{client: client, name: pkg.Name, indices: []int{i}}
+		byKey[key] = fetch
+		fetches = append(fetches, fetch)
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	queue := make(chan *batchFetch)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex // protects done and serializes calls to Progress
+		done int
+	)
+	for range min(workers, len(fetches)) {
+		wg.Go(func() {
+			for fetch := range queue {
+				found, err := fetch.client.FetchPackageReleases(ctx, fetch.name)
+
+				mu.Lock()
+				for n, i := range fetch.indices {
+					results[i].Releases, results[i].Err = found, err
+					if n > 0 {
+						// callers may mark the releases of each package differently
+						results[i].Releases.Releases = slices.Clone(found.Releases)
+					}
+					done++
+					if c.Progress != nil {
+						c.Progress(done, len(pkgs), pkgs[i])
+					}
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	for _, fetch := range fetches {
+		queue <- fetch
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
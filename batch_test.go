@@ -0,0 +1,138 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest strings sync atomic testing time github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+// newBatchServer creates a mock drupal.org and Packagist server for FetchAll, counting the requests per path.
+// Requests are delayed briefly, so that concurrent fetches overlap.
+func newBatchServer(t *testing.T, requests map[string]*atomic.Int32, concurrent *atomic.Int32, peak *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := concurrent.Add(1)
+		defer concurrent.Add(-1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		if counter, ok := requests[r.URL.Path]; ok {
+			counter.Add(1)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var body string
+		switch {
+		case r.URL.Path == "/drupal/current":
+			body = sampleCoreXML
+		case r.URL.Path == "/admin_toolbar/current":
+			body = sampleXML
+		case strings.HasPrefix(r.URL.Path, "/p2/acme/lib"):
+			name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/p2/"), ".json")
+			body = `{"packages": {"` + name + `": [{"version": "1.0.0", "version_normalized": "1.0.0.0"}]}}`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchAll(t *testing.T) {
+	t.Parallel()
+	requests := map[string]*atomic.Int32{"/drupal/current": {}, "/admin_toolbar/current": {}}
+	var concurrent, peak atomic.Int32
+	server := newBatchServer(t, requests, &concurrent, &peak)
+
+	var progress []int
+	client := drupalupdate.NewClient().WithWorkers(2).WithProgress(func(done, total int, pkg drupalupdate.Package) {
+		if total != 7 {
+			t.Errorf("expected a total of 7 packages, got %d", total)
+		}
+		progress = append(progress, done)
+	})
+	client.DrupalBaseURL = server.URL
+	client.PackagistBaseURL = server.URL
+
+	pkgs := []drupalupdate.Package{
+		{Name: "drupal/core-recommended", Version: "^11.0"},
+		{Name: "drupal/core-composer-scaffold", Version: "^11.0"},
+		{Name: "drupal/admin_toolbar", Version: "^3.5"},
+		{Name: "acme/lib1", Version: "^1.0"},
+		{Name: "acme/lib2", Version: "^1.0"},
+		{Name: "acme/missing", Version: "^1.0"},
+		{Name: "drupal/core", Version: "^11.0"},
+	}
+	results := client.FetchAll(t.Context(), pkgs)
+
+	if len(results) != len(pkgs) {
+		t.Fatalf("expected %d results, got %d", len(pkgs), len(results))
+	}
+	for i, result := range results {
+		if result.Package.Name != pkgs[i].Name {
+			t.Errorf("result %d: expected package %s, got %s", i, pkgs[i].Name, result.Package.Name)
+		}
+		wantErr := pkgs[i].Name == "acme/missing"
+		if (result.Err != nil) != wantErr {
+			t.Errorf("result %d (%s): unexpected error %v", i, pkgs[i].Name, result.Err)
+		}
+		if !wantErr && len(result.Releases.Releases) == 0 {
+			t.Errorf("result %d (%s): expected releases", i, pkgs[i].Name)
+		}
+	}
+
+	if got := requests["/drupal/current"].Load(); got != 1 {
+		t.Errorf("expected core packages to share a single fetch, got %d", got)
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", got)
+	}
+	if len(progress) != len(pkgs) || progress[len(progress)-1] != len(pkgs) {
+		t.Errorf("expected progress to be reported for each package, got %v", progress)
+	}
+
+	// releases of packages sharing a fetch can be marked independently
+	results[0].Releases.Releases[0].Relation = drupalupdate.RelationInstalled
+	if results[1].Releases.Releases[0].Relation != "" {
+		t.Error("expected packages sharing a fetch to have separate releases")
+	}
+}
+
+func TestFetchAll_ForPackage(t *testing.T) {
+	t.Parallel()
+	requests := map[string]*atomic.Int32{"/drupal/current": {}}
+	var concurrent, peak atomic.Int32
+	server := newBatchServer(t, requests, &concurrent, &peak)
+
+	client := drupalupdate.NewClient().WithPins(map[string]drupalupdate.PinStrategy{"drupal/core": drupalupdate.PinExact})
+	client.DrupalBaseURL = server.URL
+
+	// different pin strategies result in separate fetches
+	results := client.FetchAll(t.Context(), []drupalupdate.Package{
+		{Name: "drupal/core-recommended", Version: "^11.0"},
+		{Name: "drupal/core", Version: "^11.0"},
+	})
+	if got := requests["/drupal/current"].Load(); got != 2 {
+		t.Errorf("expected 2 fetches, got %d", got)
+	}
+	if pin := results[0].Releases.Releases[0].VersionPin; pin != "^11.1" {
+		t.Errorf("expected caret pin for drupal/core-recommended, got %s", pin)
+	}
+	if pin := results[1].Releases.Releases[0].VersionPin; pin != "11.1.0" {
+		t.Errorf("expected exact pin for drupal/core, got %s", pin)
+	}
+}
@@ -0,0 +1,192 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words bytes context crypto sha256 encoding json errors http path filepath time
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is the default age after which cached responses are revalidated.
+const DefaultCacheTTL = time.Hour
+
+// Cache stores the responses fetched by a [Client], keyed by their URL.
+type Cache interface {
+	// Get returns the cached entry for key, and false if there is none.
+	Get(key string) (CacheEntry, bool)
+	// Set stores the entry for key, replacing any existing entry.
+	Set(key string, entry CacheEntry) error
+}
+
+// CacheEntry is a cached response.
+type CacheEntry struct {
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`          // ETag header of the response, used for revalidation
+	LastModified string    `json:"last_modified,omitempty"` // Last-Modified header of the response, used for revalidation
+	Fetched      time.Time `json:"fetched"`                 // time the response was fetched or last revalidated
+}
+
+// fresh reports whether the entry was fetched less than ttl before now.
+// A ttl that is not positive is treated as [DefaultCacheTTL].
+func (e CacheEntry) fresh(ttl time.Duration, now time.Time) bool {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return now.Sub(e.Fetched) < ttl
+}
+
+// fetchCached fetches a URL like fetchResponse, using the cache of client.
+// Fresh entries are used without a request, stale entries are revalidated using their ETag or Last-Modified header.
+// Only responses that can be parsed are stored, and failing to store a response does not fail the fetch.
+func fetchCached[T any](ctx context.Context, client *Client, url string, parser func(io.Reader) (T, error)) (T, error) {
+	entry, cached := client.Cache.Get(url)
+	now := time.Now()
+	if cached && entry.fresh(client.CacheTTL, now) {
+		return parser(bytes.NewReader(entry.Body))
+	}
+
+	body, err := doRequest(ctx, client, url, func(req *http.Request) {
+		if !cached {
+			return
+		}
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}, func(resp *http.Response) ([]byte, error) {
+		if cached && resp.StatusCode == http.StatusNotModified {
+			return entry.Body, nil
+		}
+		if err := checkStatus(resp); err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		entry = CacheEntry{Body: data, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		return data, nil
+	})
+	if err != nil {
+		var t T
+		return t, err
+	}
+
+	result, err := parser(bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	entry.Fetched = now
+	_ = client.Cache.Set(url, entry) // a failing cache must not fail the request
+	return result, nil
+}
+
+// =============================================================================
+// Disk Cache
+// =============================================================================
+
+// DiskCache is a [Cache] storing each entry as a file in a directory.
+type DiskCache struct {
+	Dir string // directory of the cache files, created when needed
+}
+
+// NewDiskCache returns a DiskCache storing its entries in dir.
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{Dir: dir}
+}
+
+// DefaultCacheDir returns the default directory of a [DiskCache],
+// the "composer-drupal-update" directory in the user cache directory, e.g. "$XDG_CACHE_HOME/composer-drupal-update".
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cache directory: %w", err)
+	}
+	return filepath.Join(dir, "composer-drupal-update"), nil
+}
+
+// path returns the path of the file storing the entry for key.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements [Cache].
+// Entries that cannot be read are treated as missing.
+func (d *DiskCache) Get(key string) (CacheEntry, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set implements [Cache].
+// The entry is written to a temporary file first, so that concurrent readers never see a partial entry.
+func (d *DiskCache) Set(key string, entry CacheEntry) (e error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(d.Dir, 0o750); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(d.Dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+	defer func() {
+		if e != nil {
+			e = errors.Join(e, os.Remove(file.Name()))
+		}
+	}()
+	if _, err := file.Write(data); err != nil {
+		return errors.Join(fmt.Errorf("write cache entry: %w", err), file.Close())
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := os.Rename(file.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("store cache entry: %w", err)
+	}
+	return nil
+}
+
+// Clear removes all entries of the cache, and leftover temporary files.
+// Other files in the cache directory are kept, and a missing cache directory is not an error.
+func (d *DiskCache) Clear() error {
+	entries, err := os.ReadDir(d.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read cache directory: %w", err)
+	}
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); entry.IsDir() || (ext != ".json" && ext != ".tmp") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.Dir, entry.Name())); err != nil {
+			return fmt.Errorf("clear cache: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest path filepath sync atomic testing time github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestDiskCache(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "cache")
+	cache := drupalupdate.NewDiskCache(dir)
+
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Fatal("expected an empty cache")
+	}
+
+	entry := drupalupdate.CacheEntry{Body: []byte(`{"a": 1}`), ETag: `"v1"`, Fetched: time.Date(2024, 11, 19, 10, 0, 0, 0, time.UTC)}
+	if err := cache.Set("https://example.com/a", entry); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	got, ok := cache.Get("https://example.com/a")
+	if !ok || string(got.Body) != `{"a": 1}` || got.ETag != `"v1"` || !got.Fetched.Equal(entry.Fetched) {
+		t.Errorf("unexpected entry: %+v, %v", got, ok)
+	}
+	if _, ok := cache.Get("https://example.com/b"); ok {
+		t.Error("expected no entry for another key")
+	}
+
+	other := filepath.Join(dir, "README")
+	if err := os.WriteFile(other, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Error("expected the entry to be cleared")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expected other files to be kept: %v", err)
+	}
+}
+
+func TestDiskCache_ClearMissing(t *testing.T) {
+	t.Parallel()
+	cache := drupalupdate.NewDiskCache(filepath.Join(t.TempDir(), "missing"))
+	if err := cache.Clear(); err != nil {
+		t.Errorf("Clear returned error for a missing directory: %v", err)
+	}
+}
+
+func TestFetchReleases_Cache(t *testing.T) {
+	t.Parallel()
+	var requests, revalidations atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if _, err := w.Write([]byte(samplePackagistJSON)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient().WithCache(drupalupdate.NewDiskCache(t.TempDir()), time.Hour)
+	client.DrupalBaseURL = ""
+	client.PackagistBaseURL = server.URL
+
+	for range 2 {
+		releases, err := client.FetchReleases(t.Context(), "drush/drush")
+		if err != nil {
+			t.Fatalf("FetchReleases returned error: %v", err)
+		}
+		if len(releases) != 3 {
+			t.Errorf("expected 3 releases, got %+v", releases)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected a fresh entry to be used without a request, got %d requests", got)
+	}
+
+	// an expired entry is revalidated using its ETag
+	client.CacheTTL = time.Nanosecond
+	releases, err := client.FetchReleases(t.Context(), "drush/drush")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) != 3 {
+		t.Errorf("expected 3 releases from the revalidated entry, got %+v", releases)
+	}
+	if requests.Load() != 2 || revalidations.Load() != 1 {
+		t.Errorf("expected a single revalidation, got %d requests and %d revalidations", requests.Load(), revalidations.Load())
+	}
+}
+
+func TestFetchReleases_CacheErrors(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient().WithCache(drupalupdate.NewDiskCache(t.TempDir()), time.Hour)
+	client.DrupalBaseURL = ""
+	client.PackagistBaseURL = server.URL
+
+	for range 2 {
+		if _, err := client.FetchReleases(t.Context(), "drush/drush"); err == nil {
+			t.Error("expected an error")
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected failed responses not to be cached, got %d requests", got)
+	}
+}
+
+func TestFetchReleases_CacheZeroTTL(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if _, err := w.Write([]byte(samplePackagistJSON)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	// a zero ttl means the default ttl
+	client := drupalupdate.NewClient().WithCache(drupalupdate.NewDiskCache(t.TempDir()), 0)
+	client.DrupalBaseURL = ""
+	client.PackagistBaseURL = server.URL
+
+	for range 2 {
+		if _, err := client.FetchReleases(t.Context(), "drush/drush"); err != nil {
+			t.Fatalf("FetchReleases returned error: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected the entry to be fresh with a zero ttl, got %d requests", got)
+	}
+}
+
+func TestFetchReleases_CacheInvalid(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := samplePackagistJSON
+		if requests.Add(1) == 1 {
+			body = "not json"
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient().WithCache(drupalupdate.NewDiskCache(t.TempDir()), time.Hour)
+	client.DrupalBaseURL = ""
+	client.PackagistBaseURL = server.URL
+
+	if _, err := client.FetchReleases(t.Context(), "drush/drush"); err == nil {
+		t.Error("expected an error for an invalid response")
+	}
+	releases, err := client.FetchReleases(t.Context(), "drush/drush")
+	if err != nil {
+		t.Fatalf("expected invalid responses not to be cached, got error: %v", err)
+	}
+	if len(releases) != 3 {
+		t.Errorf("expected 3 releases, got %+v", releases)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
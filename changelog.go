@@ -0,0 +1,245 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words context encoding json errors html http regexp strings
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// =============================================================================
+// Release Notes
+// =============================================================================
+
+// NotesFetcher fetches the release notes of a single release of a package.
+type NotesFetcher interface {
+	// FetchNotes returns the release notes as plain text or markdown, or "" if the release has none.
+	FetchNotes(ctx context.Context, pkg string, release Release) (string, error)
+}
+
+// NotesFetcherFunc is a function implementing [NotesFetcher].
+type NotesFetcherFunc func(ctx context.Context, pkg string, release Release) (string, error)
+
+// FetchNotes calls f.
+func (f NotesFetcherFunc) FetchNotes(ctx context.Context, pkg string, release Release) (string, error) {
+	return f(ctx, pkg, release)
+}
+
+// ReleaseNotes are the release notes of a single release.
+type ReleaseNotes struct {
+	Version string `json:"version"`
+	URL     string `json:"url,omitempty"`   // page of the release, if known
+	Notes   string `json:"notes"`           // plain text or markdown, "" if the release has none
+	Error   string `json:"error,omitempty"` // error fetching the notes, if any
+}
+
+var errChangelogTarget = errors.New("target must be a numbered version")
+
+// FetchChangelog fetches the release notes of all releases of pkg newer than from, up to and including to.
+// The result is ordered newest first.
+//
+// from is either the installed version, or the current constraint whose lower bound is used, e.g. "^1.2".
+// Releases less stable than allowed by the stability settings of c are skipped, unless the target itself is.
+// Failing to fetch the notes of a single release is reported in its Error instead of failing the changelog.
+func (c *Client) FetchChangelog(ctx context.Context, pkg, from, to string) ([]ReleaseNotes, error) {
+	target := ParseVersion(strings.TrimPrefix(to, "v"))
+	if target.Major < 0 || target.Minor == WildcardSegment {
+		return nil, fmt.Errorf("%w: %q", errChangelogTarget, to)
+	}
+	base := ParseVersion(strings.TrimPrefix(from, "v"))
+	if base.Major < 0 || base.Minor == WildcardSegment {
+		base = ParseVersion("")
+		if constraint, err := ParseConstraint(from); err == nil {
+			base = constraint.base()
+			base.Stability, base.StabilityNumber = StabilityStable, -1 // releases of the bound itself are allowed already
+		}
+	}
+
+	client := *c
+	client.Mode, client.Include = ModeAll, nil
+	client.PlatformPHP = ""
+	if !client.Stability.allows(target.Stability) {
+		client.Stability.MinimumStability = target.Stability
+	}
+	found, err := client.fetchReleases(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := c.NotesFetcher
+	if fetcher == nil {
+		fetcher = NotesFetcherFunc(c.FetchReleaseNotes)
+	}
+
+	var changelog []ReleaseNotes
+	for _, release := range found.Releases {
+		v := ParseVersion(release.Version)
+		if v.Major < 0 || v.Minor == WildcardSegment || v.compareRelease(target) > 0 {
+			continue
+		}
+		if base.Major >= 0 && v.compareRelease(base) <= 0 {
+			continue
+		}
+
+		notes := ReleaseNotes{Version: release.Version, URL: release.ReleaseLink}
+		text, err := fetcher.FetchNotes(ctx, pkg, release)
+		if err != nil {
+			notes.Error = err.Error()
+		}
+		notes.Notes = text
+		changelog = append(changelog, notes)
+	}
+	return changelog, nil
+}
+
+// FormatChangelog formats release notes as markdown, with a heading per release.
+func FormatChangelog(changelog []ReleaseNotes) string {
+	var b strings.Builder
+	for i, notes := range changelog {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + notes.Version + "\n\n")
+		switch {
+		case notes.Error != "":
+			b.WriteString("Could not fetch release notes: " + notes.Error + "\n")
+		case notes.Notes == "":
+			b.WriteString("No release notes.\n")
+		default:
+			b.WriteString(strings.TrimSpace(notes.Notes) + "\n")
+		}
+		if notes.URL != "" {
+			b.WriteString("\nSee " + notes.URL + "\n")
+		}
+	}
+	return b.String()
+}
+
+// FetchReleaseNotes fetches the release notes of a release.
+// It is the default [NotesFetcher] of c.
+//
+// Notes of drupal.org releases are taken from the body of their release page and converted to plain text.
+// Notes of releases with a source repository on GitHub are taken from the GitHub release of their tag.
+// Other releases have no notes.
+func (c *Client) FetchReleaseNotes(ctx context.Context, pkg string, release Release) (string, error) {
+	if release.ReleaseLink != "" {
+		return fetchResponse(ctx, c, release.ReleaseLink, func(body io.Reader) (string, error) {
+			page, err := io.ReadAll(body)
+			if err != nil {
+				return "", fmt.Errorf("read release page: %w", err)
+			}
+			return htmlToText(drupalReleaseBody(string(page))), nil
+		})
+	}
+
+	owner, repo, ok := githubRepository(release.SourceURL)
+	if !ok || c.GitHubBaseURL == "" {
+		return "", nil
+	}
+	for _, tag := range []string{release.Version, "v" + release.Version} {
+		notes, err := fetchResponse(ctx, c, fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", c.GitHubBaseURL, owner, repo, url.PathEscape(tag)), func(body io.Reader) (string, error) {
+			var result struct {
+				Body string `json:"body"`
+			}
+			if err := json.NewDecoder(body).Decode(&result); err != nil {
+				return "", fmt.Errorf("decode JSON: %w", err)
+			}
+			return result.Body, nil
+		})
+		if errors.Is(err, errHTTPNotFound) {
+			continue
+		}
+		return notes, err
+	}
+	return "", nil
+}
+
+// githubRepository returns the owner and name of a repository on GitHub from its URL,
+// e.g. "https://github.com/drush-ops/drush.git" → "drush-ops", "drush".
+func githubRepository(source string) (owner, repo string, ok bool) {
+	u, err := url.Parse(source)
+	if err != nil || !strings.EqualFold(u.Host, "github.com") {
+		return "", "", false
+	}
+	owner, repo, ok = strings.Cut(strings.Trim(u.Path, "/"), "/")
+	repo = strings.TrimSuffix(repo, ".git")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return owner, repo, true
+}
+
+// =============================================================================
+// HTML Conversion
+// =============================================================================
+
+var (
+	// htmlItemRegex matches the start of list items, including preceding whitespace.
+	htmlItemRegex = regexp.MustCompile(`(?i)\s*<li\b[^>]*>`)
+	// htmlBreakRegex matches tags ending a line.
+	htmlBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>|</(?:p|div|h[1-6]|ul|ol|pre|tr)>`)
+	// htmlTagRegex matches any other tag or comment.
+	htmlTagRegex = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+	// blankLinesRegex matches more than one blank line.
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// drupalReleaseBody returns the inner HTML of the body field of a drupal.org release page, or "" if there is none.
+func drupalReleaseBody(page string) string {
+	idx := -1
+	for _, marker := range []string{"field--name-body", "field-name-body"} {
+		if idx = strings.Index(page, marker); idx >= 0 {
+			break
+		}
+	}
+	if idx < 0 {
+		return ""
+	}
+	open := strings.IndexByte(page[idx:], '>')
+	if open < 0 {
+		return ""
+	}
+
+	// find the matching closing tag of the field
+	rest := page[idx+open+1:]
+	depth := 1
+	for pos := 0; ; {
+		next := strings.Index(rest[pos:], "<div")
+		end := strings.Index(rest[pos:], "</div")
+		switch {
+		case end < 0:
+			return rest
+		case next >= 0 && next < end:
+			depth++
+			pos += next + len("<div")
+		default:
+			depth--
+			if depth == 0 {
+				return rest[:pos+end]
+			}
+			pos += end + len("</div")
+		}
+	}
+}
+
+// htmlToText converts an HTML fragment to plain text, keeping line breaks and list items.
+func htmlToText(fragment string) string {
+	text := htmlItemRegex.ReplaceAllString(fragment, "\n- ")
+	text = htmlBreakRegex.ReplaceAllString(text, "\n")
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	text = blankLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,77 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words testing
+import "testing"
+
+func TestDrupalReleaseBody(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "nested divs",
+			page: `<div class="field field--name-body"><div><p>Notes</p></div></div><div>Footer</div>`,
+			want: `<div><p>Notes</p></div>`,
+		},
+		{
+			name: "legacy class",
+			page: `<div class="field field-name-body field-type-text-with-summary"><p>Notes</p></div>`,
+			want: `<p>Notes</p>`,
+		},
+		{
+			name: "no body",
+			page: `<div class="field--name-title">Title</div>`,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalReleaseBody(tt.page); got != tt.want {
+				t.Errorf("drupalReleaseBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		html string
+		want string
+	}{
+		{"<p>First</p><p>Second &lt;3</p>", "First\nSecond <3"},
+		{"<h3>Changes</h3><ul>\n<li><a href=\"#\">#123</a>: Fix   it</li>\n<li>Add it</li>\n</ul>", "Changes\n\n- #123: Fix it\n- Add it"},
+		{"Line<br>break<br/>here<!-- comment -->", "Line\nbreak\nhere"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := htmlToText(tt.html); got != tt.want {
+			t.Errorf("htmlToText(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubRepository(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		source      string
+		owner, repo string
+		ok          bool
+	}{
+		{"https://github.com/drush-ops/drush.git", "drush-ops", "drush", true},
+		{"https://github.com/symfony/console", "symfony", "console", true},
+		{"https://gitlab.com/group/project.git", "", "", false},
+		{"https://github.com/drush-ops", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := githubRepository(tt.source)
+		if owner != tt.owner || repo != tt.repo || ok != tt.ok {
+			t.Errorf("githubRepository(%q) = %q, %q, %v, want %q, %q, %v", tt.source, owner, repo, ok, tt.owner, tt.repo, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words context errors http httptest slices strings testing github composer drupal update drupalupdate
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+// sampleChangelogXML is a drupal.org release history response with release pages under "{base}".
+const sampleChangelogXML = `<?xml version="1.0" encoding="utf-8"?>
+<project xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <title>Admin Toolbar</title>
+  <short_name>admin_toolbar</short_name>
+  <project_status>published</project_status>
+  <supported_branches>3.0.,4.0.</supported_branches>
+  <releases>
+    <release>
+      <name>admin_toolbar 4.0.2</name>
+      <version>4.0.2</version>
+      <status>published</status>
+      <release_link>{base}/project/admin_toolbar/releases/4.0.2</release_link>
+    </release>
+    <release>
+      <name>admin_toolbar 4.0.1</name>
+      <version>4.0.1</version>
+      <status>published</status>
+      <release_link>{base}/project/admin_toolbar/releases/4.0.1</release_link>
+    </release>
+    <release>
+      <name>admin_toolbar 4.0.0</name>
+      <version>4.0.0</version>
+      <status>published</status>
+      <release_link>{base}/project/admin_toolbar/releases/4.0.0</release_link>
+    </release>
+    <release>
+      <name>admin_toolbar 3.0.5</name>
+      <version>3.0.5</version>
+      <status>published</status>
+      <release_link>{base}/project/admin_toolbar/releases/3.0.5</release_link>
+    </release>
+  </releases>
+</project>`
+
+// sampleReleasePage is a drupal.org release page.
+const sampleReleasePage = `<html><body>
+<h1>admin_toolbar %s</h1>
+<div class="field field--name-body field--type-text-with-summary">
+  <div class="field__item"><p>Changes since the last release:</p>
+    <ul><li>Fixed toolbar &amp; icons</li><li>Added search</li></ul>
+  </div>
+</div>
+<div class="footer">Footer</div>
+</body></html>`
+
+// newChangelogClient creates a Client backed by a mock drupal.org server serving release pages.
+func newChangelogClient(t *testing.T) *drupalupdate.Client {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin_toolbar/current" {
+			if _, err := w.Write([]byte(strings.ReplaceAll(sampleChangelogXML, "{base}", server.URL))); err != nil {
+				return
+			}
+			return
+		}
+		if version, ok := strings.CutPrefix(r.URL.Path, "/project/admin_toolbar/releases/"); ok {
+			if _, err := w.Write([]byte(strings.Replace(sampleReleasePage, "%s", version, 1))); err != nil {
+				return
+			}
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = server.URL
+	client.PackagistBaseURL = server.URL
+	return client
+}
+
+func TestFetchChangelog_Drupal(t *testing.T) {
+	t.Parallel()
+	client := newChangelogClient(t)
+
+	changelog, err := client.FetchChangelog(t.Context(), "drupal/admin_toolbar", "4.0.0", "4.0.2")
+	if err != nil {
+		t.Fatalf("FetchChangelog returned error: %v", err)
+	}
+
+	var versions []string
+	for _, notes := range changelog {
+		versions = append(versions, notes.Version)
+	}
+	if want := []string{"4.0.2", "4.0.1"}; !slices.Equal(versions, want) {
+		t.Fatalf("versions = %v, want %v", versions, want)
+	}
+
+	want := "Changes since the last release:\n\n- Fixed toolbar & icons\n- Added search"
+	if changelog[0].Notes != want {
+		t.Errorf("notes = %q, want %q", changelog[0].Notes, want)
+	}
+	if !strings.HasSuffix(changelog[0].URL, "/project/admin_toolbar/releases/4.0.2") {
+		t.Errorf("url = %q, want the release page", changelog[0].URL)
+	}
+}
+
+func TestFetchChangelog_Constraint(t *testing.T) {
+	t.Parallel()
+	client := newChangelogClient(t)
+
+	tests := []struct {
+		from string
+		want []string
+	}{
+		{"^4.0", []string{"4.0.1"}},
+		{"^3.0", []string{"4.0.1", "4.0.0", "3.0.5"}},
+		{"", []string{"4.0.1", "4.0.0", "3.0.5"}},
+	}
+	for _, tt := range tests {
+		changelog, err := client.FetchChangelog(t.Context(), "drupal/admin_toolbar", tt.from, "4.0.1")
+		if err != nil {
+			t.Fatalf("FetchChangelog(%q) returned error: %v", tt.from, err)
+		}
+		var versions []string
+		for _, notes := range changelog {
+			versions = append(versions, notes.Version)
+		}
+		if !slices.Equal(versions, tt.want) {
+			t.Errorf("FetchChangelog(%q) versions = %v, want %v", tt.from, versions, tt.want)
+		}
+	}
+}
+
+func TestFetchChangelog_GitHub(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/p2/drush/drush.json":
+			if _, err := w.Write([]byte(`{"packages": {"drush/drush": [
+				{"version": "13.0.1", "version_normalized": "13.0.1.0", "source": {"url": "https://github.com/drush-ops/drush.git"}},
+				{"version": "13.0.0", "version_normalized": "13.0.0.0", "source": {"url": "https://github.com/drush-ops/drush.git"}},
+				{"version": "12.5.6", "version_normalized": "12.5.6.0", "source": {"url": "https://github.com/drush-ops/drush.git"}}
+			]}}`)); err != nil {
+				return
+			}
+		case "/repos/drush-ops/drush/releases/tags/13.0.1":
+			if _, err := w.Write([]byte(`{"tag_name": "13.0.1", "body": "## Fixes\n\n* Fix sql:sync"}`)); err != nil {
+				return
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.PackagistBaseURL = server.URL
+	client.GitHubBaseURL = server.URL
+
+	changelog, err := client.FetchChangelog(t.Context(), "drush/drush", "12.5.6", "13.0.1")
+	if err != nil {
+		t.Fatalf("FetchChangelog returned error: %v", err)
+	}
+	want := []drupalupdate.ReleaseNotes{
+		{Version: "13.0.1", Notes: "## Fixes\n\n* Fix sql:sync"},
+		{Version: "13.0.0"}, // no GitHub release
+	}
+	if !slices.Equal(changelog, want) {
+		t.Errorf("changelog = %+v, want %+v", changelog, want)
+	}
+}
+
+func TestFetchChangelog_NotesFetcher(t *testing.T) {
+	t.Parallel()
+	errFetch := errors.New("fetch failed")
+	client := newChangelogClient(t).WithNotesFetcher(drupalupdate.NotesFetcherFunc(func(_ context.Context, pkg string, release drupalupdate.Release) (string, error) {
+		if release.Version == "4.0.1" {
+			return "", errFetch
+		}
+		return pkg + " " + release.Version, nil
+	}))
+
+	changelog, err := client.FetchChangelog(t.Context(), "drupal/admin_toolbar", "^4.0", "4.0.2")
+	if err != nil {
+		t.Fatalf("FetchChangelog returned error: %v", err)
+	}
+	if len(changelog) != 2 {
+		t.Fatalf("expected 2 releases, got %+v", changelog)
+	}
+	if changelog[0].Notes != "drupal/admin_toolbar 4.0.2" || changelog[0].Error != "" {
+		t.Errorf("changelog[0] = %+v, want notes from the fetcher", changelog[0])
+	}
+	if changelog[1].Error != errFetch.Error() {
+		t.Errorf("changelog[1].Error = %q, want %q", changelog[1].Error, errFetch.Error())
+	}
+}
+
+func TestFetchChangelog_InvalidTarget(t *testing.T) {
+	t.Parallel()
+	client := newChangelogClient(t)
+
+	for _, to := range []string{"", "dev-main", "4.x-dev"} {
+		if _, err := client.FetchChangelog(t.Context(), "drupal/admin_toolbar", "^4.0", to); err == nil {
+			t.Errorf("FetchChangelog(%q) expected error", to)
+		}
+	}
+}
+
+func TestFormatChangelog(t *testing.T) {
+	t.Parallel()
+	got := drupalupdate.FormatChangelog([]drupalupdate.ReleaseNotes{
+		{Version: "4.0.2", Notes: "- Fixed icons\n", URL: "https://www.drupal.org/project/admin_toolbar/releases/4.0.2"},
+		{Version: "4.0.1"},
+		{Version: "4.0.0", Error: "unexpected HTTP status: 403"},
+	})
+	want := "## 4.0.2\n\n- Fixed icons\n\nSee https://www.drupal.org/project/admin_toolbar/releases/4.0.2\n" +
+		"\n## 4.0.1\n\nNo release notes.\n" +
+		"\n## 4.0.0\n\nCould not fetch release notes: unexpected HTTP status: 403\n"
+	if got != want {
+		t.Errorf("FormatChangelog() = %q, want %q", got, want)
+	}
+}
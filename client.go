@@ -4,7 +4,7 @@
 //spellchecker:words drupalupdate
 package drupalupdate
 
-//spellchecker:words context encoding json errors http slices strings regexp
+//spellchecker:words context encoding json errors http slices strings regexp time
 import (
 	"context"
 	"errors"
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"regexp"
 	"slices"
+	"time"
 )
 
 // =============================================================================
@@ -21,16 +22,32 @@ import (
 
 // Release represents a single release from drupal.org or Packagist.
 type Release struct {
-	Name              string `json:"name"                         xml:"name"`
-	Version           string `json:"version"                      xml:"version"`
-	VersionPin        string `json:"version_pin"`
-	Status            string `json:"-"                            xml:"status"`
-	CoreCompatibility string `json:"core_compatibility,omitempty" xml:"core_compatibility"`
+	Name              string            `json:"name"                         xml:"name"`
+	Version           string            `json:"version"                      xml:"version"`
+	VersionPin        string            `json:"version_pin"`
+	Status            string            `json:"-"                            xml:"status"`
+	CoreCompatibility string            `json:"core_compatibility,omitempty" xml:"core_compatibility"`
+	PHP               string            `json:"php,omitempty"                xml:"-"`            // PHP requirement, e.g. ">=8.1", if known
+	Security          bool              `json:"security,omitempty"           xml:"-"`            // release is a security update
+	SecurityVersion   string            `json:"security_version,omitempty"   xml:"-"`            // newest security release of the branch up to this release, if any
+	Stability         string            `json:"stability,omitempty"          xml:"-"`            // stability of the release, e.g. "stable", "RC" or "dev"
+	Branch            string            `json:"branch,omitempty"             xml:"-"`            // branch of the release, see [Version.ReleaseBranch]
+	Date              time.Time         `json:"date,omitzero"                xml:"-"`            // date of the release, if known
+	Require           map[string]string `json:"require,omitempty"            xml:"-"`            // composer requirements of the release, if known
+	ReleaseLink       string            `json:"release_link,omitempty"       xml:"release_link"` // page of drupal.org releases, including the release notes
+	SourceURL         string            `json:"source_url,omitempty"         xml:"-"`            // source repository of Packagist releases, e.g. on GitHub
+	Advisories        []Advisory        `json:"advisories,omitempty"         xml:"-"`            // security advisories affecting the release, see [MarkAdvisories]
+
+	Relation         ReleaseRelation `json:"relation,omitempty"          xml:"-"` // relation to the installed version, if known
+	Allowed          bool            `json:"allowed,omitempty"           xml:"-"` // release already satisfies the current constraint
+	CoreIncompatible bool            `json:"core_incompatible,omitempty" xml:"-"` // release does not support the targeted drupal core version
+	Update           UpdateType      `json:"update,omitempty"            xml:"-"` // size of the update from the current constraint, if known
 }
 
 // errHTTPStatus is returned when an HTTP request returns a non-OK status.
 var (
-	errHTTPStatus = errors.New("unexpected HTTP status")
+	errHTTPStatus   = errors.New("unexpected HTTP status")
+	errHTTPNotFound = fmt.Errorf("%w: %d", errHTTPStatus, http.StatusNotFound)
 )
 
 // =============================================================================
@@ -43,6 +60,15 @@ const (
 
 	// DefaultPackagistBaseURL is the default base URL for the Packagist p2 API.
 	DefaultPackagistBaseURL = "https://repo.packagist.org"
+
+	// DefaultPackagistAPIURL is the default base URL for the Packagist API, used for security advisories.
+	DefaultPackagistAPIURL = "https://packagist.org"
+
+	// DefaultDrupalComposerURL is the default URL of the drupal.org composer repository.
+	DefaultDrupalComposerURL = "https://packages.drupal.org/8"
+
+	// DefaultGitHubBaseURL is the default base URL for the GitHub REST API, used for release notes.
+	DefaultGitHubBaseURL = "https://api.github.com"
 )
 
 // Client fetches release information from drupal.org and Packagist.
@@ -50,43 +76,319 @@ const (
 type Client struct {
 	HTTPClient *http.Client
 
-	DrupalBaseURL    string // base URL for drupal updates release history API
-	PackagistBaseURL string // base URL for packagist p2 API
+	DrupalBaseURL     string // base URL for drupal updates release history API
+	PackagistBaseURL  string // base URL for packagist p2 API
+	PackagistAPIURL   string // base URL for the Packagist API, security advisories are skipped if empty
+	DrupalComposerURL string // URL of the drupal.org composer repository, used by [DrupalSourceComposer]
+	GitHubBaseURL     string // base URL for the GitHub REST API, release notes from GitHub are skipped if empty
+
+	Repositories     Repositories           // custom repositories from composer.json, consulted before drupal.org and Packagist
+	RepositoryPolicy *RepositoryPolicy      // restricts fetching from Repositories, every repository is used if nil
+	Stability        StabilitySettings      // stability settings deciding which releases are offered
+	PlatformPHP      string                 // PHP version of the platform, releases requiring another version are skipped
+	PinStrategy      PinStrategy            // strategy for the version pins of releases, defaults to [PinCaret]
+	Pins             map[string]PinStrategy // pin strategies of single packages used by [Client.ForPackage], overriding PinStrategy
+	NotesFetcher     NotesFetcher           // fetches release notes, defaults to [Client.FetchReleaseNotes]
+	Mode             ReleaseMode            // which releases are fetched, defaults to [ModeLatest]
+	Include          []string               // stabilities offered in [ModeAll] in addition to those allowed by Stability
+	DrupalSource     DrupalSource           // source of releases of drupal/* modules, defaults to [DrupalSourceXML]
+	Projects         map[string]string      // drupal.org projects of drupal/* packages not named after their project, e.g. sub-modules
+	Auth             Auth                   // credentials sent to private repositories per host, e.g. from [LoadAuth], but not to those restricted by RepositoryPolicy
+	Cache            Cache                  // cache of fetched responses, responses are not cached if nil
+	CacheTTL         time.Duration          // age after which cached responses are revalidated, [DefaultCacheTTL] if not positive
+	Workers          int                    // maximum number of concurrent fetches of [Client.FetchAll], defaults to [DefaultWorkers]
+	Progress         ProgressFunc           // called by [Client.FetchAll] after fetching each package, if not nil
+
+	indexes *repositoryIndexes // packages.json files fetched during a single [Client.FetchAll], if any
 }
 
 // NewClient creates a Client that talks to the real drupal.org and Packagist APIs.
 func NewClient() *Client {
 	return &Client{
-		DrupalBaseURL:    DefaultDrupalBaseURL,
-		PackagistBaseURL: DefaultPackagistBaseURL,
-		HTTPClient:       http.DefaultClient,
+		DrupalBaseURL:     DefaultDrupalBaseURL,
+		PackagistBaseURL:  DefaultPackagistBaseURL,
+		PackagistAPIURL:   DefaultPackagistAPIURL,
+		DrupalComposerURL: DefaultDrupalComposerURL,
+		GitHubBaseURL:     DefaultGitHubBaseURL,
+		HTTPClient:        &http.Client{CheckRedirect: checkRedirect},
 	}
 }
 
+// WithRepositories returns a copy of c that uses the given custom repositories.
+func (c *Client) WithRepositories(repos Repositories) *Client {
+	clone := *c
+	clone.Repositories = repos
+	return &clone
+}
+
+// WithRepositoryPolicy returns a copy of c that only fetches from the custom repositories allowed by policy.
+func (c *Client) WithRepositoryPolicy(policy *RepositoryPolicy) *Client {
+	clone := *c
+	clone.RepositoryPolicy = policy
+	return &clone
+}
+
+// WithStability returns a copy of c that uses the given stability settings.
+func (c *Client) WithStability(settings StabilitySettings) *Client {
+	clone := *c
+	clone.Stability = settings
+	return &clone
+}
+
+// WithPlatformPHP returns a copy of c that skips releases that cannot be installed on the given PHP version.
+// An empty version does not skip any release.
+func (c *Client) WithPlatformPHP(php string) *Client {
+	clone := *c
+	clone.PlatformPHP = php
+	return &clone
+}
+
+// WithPinStrategy returns a copy of c that pins releases using the given strategy.
+// Resolve [PinKeep] using [PinStrategy.ForConstraint] first, otherwise releases are pinned using [PinCaret].
+func (c *Client) WithPinStrategy(strategy PinStrategy) *Client {
+	clone := *c
+	clone.PinStrategy = strategy
+	return &clone
+}
+
+// WithPins returns a copy of c that uses the given pin strategies for single packages, see [Client.ForPackage].
+func (c *Client) WithPins(pins map[string]PinStrategy) *Client {
+	clone := *c
+	clone.Pins = pins
+	return &clone
+}
+
+// WithMode returns a copy of c that fetches releases using the given mode.
+// In [ModeAll], releases with the included stabilities are offered in addition to those allowed by the stability settings.
+func (c *Client) WithMode(mode ReleaseMode, include ...string) *Client {
+	clone := *c
+	clone.Mode = mode
+	clone.Include = include
+	return &clone
+}
+
+// WithDrupalSource returns a copy of c that fetches releases of drupal/* packages from the given source.
+func (c *Client) WithDrupalSource(source DrupalSource) *Client {
+	clone := *c
+	clone.DrupalSource = source
+	return &clone
+}
+
+// WithProjects returns a copy of c that maps drupal/* packages to the given drupal.org projects,
+// e.g. "drupal/admin_toolbar_tools" to "admin_toolbar".
+func (c *Client) WithProjects(projects map[string]string) *Client {
+	clone := *c
+	clone.Projects = projects
+	return &clone
+}
+
+// WithAuth returns a copy of c that sends the given credentials to private repositories.
+func (c *Client) WithAuth(auth Auth) *Client {
+	clone := *c
+	clone.Auth = auth
+	return &clone
+}
+
+// WithCache returns a copy of c that caches fetched responses in cache, revalidating them after ttl.
+// A nil cache disables caching, and a ttl that is not positive means [DefaultCacheTTL].
+func (c *Client) WithCache(cache Cache, ttl time.Duration) *Client {
+	clone := *c
+	clone.Cache = cache
+	clone.CacheTTL = ttl
+	return &clone
+}
+
+// WithWorkers returns a copy of c that fetches at most the given number of packages concurrently in [Client.FetchAll].
+func (c *Client) WithWorkers(workers int) *Client {
+	clone := *c
+	clone.Workers = workers
+	return &clone
+}
+
+// WithProgress returns a copy of c that reports the progress of [Client.FetchAll] to progress.
+func (c *Client) WithProgress(progress ProgressFunc) *Client {
+	clone := *c
+	clone.Progress = progress
+	return &clone
+}
+
+// WithNotesFetcher returns a copy of c that fetches release notes using the given fetcher.
+func (c *Client) WithNotesFetcher(fetcher NotesFetcher) *Client {
+	clone := *c
+	clone.NotesFetcher = fetcher
+	return &clone
+}
+
+// PackageReleases holds the releases of a package together with information about its project.
+type PackageReleases struct {
+	Releases []Release
+
+	Project           string        // drupal.org project of drupal/* packages, e.g. the parent project of sub-modules, or "" if unknown
+	Status            ProjectStatus // status of the drupal.org project, or "" if unknown
+	SupportedBranches []string      // branches supported by the drupal.org project, if known
+
+	Abandoned   bool   // the package is marked as abandoned in its composer metadata
+	Replacement string // package suggested to replace an abandoned package, if any
+}
+
 // FetchReleases fetches releases for any composer package.
-// For drupal core packages (drupal/core, drupal/core-recommended, etc.),
-// it queries the "drupal" project on drupal.org.
-// For other drupal/* packages, it queries drupal.org by module name.
-// For all other packages, it queries Packagist.
+// The version pin of each release follows the pin strategy of c.
+//
+// Custom repositories are consulted first, in order, like composer would.
+// Releases are merged until a canonical repository provides the package.
+// Afterwards, drupal core packages (drupal/core, drupal/core-recommended, etc.)
+// are looked up as the "drupal" project on drupal.org,
+// other drupal/* packages are looked up on drupal.org by module name,
+// and all other packages on Packagist, unless it has been disabled.
 func (c *Client) FetchReleases(ctx context.Context, pkg string) ([]Release, error) {
+	found, err := c.FetchPackageReleases(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	return found.Releases, nil
+}
+
+// FetchPackageReleases fetches releases for any composer package like [Client.FetchReleases].
+// For packages found on drupal.org, the status and supported branches of the project are included.
+//
+// In [ModeAll], releases pinned using [PinCaret] keep their patch segment like [PinCaretPatch],
+// so that each release can be pinned.
+func (c *Client) FetchPackageReleases(ctx context.Context, pkg string) (PackageReleases, error) {
+	found, err := c.fetchReleases(ctx, pkg)
+	if err != nil {
+		return PackageReleases{}, err
+	}
+	strategy := c.PinStrategy
+	if c.Mode == ModeAll && (strategy == "" || strategy == PinCaret) {
+		strategy = PinCaretPatch
+	}
+	if strategy != "" && strategy != PinCaret {
+		ApplyPinStrategy(found.Releases, strategy, "")
+	}
+	return found, nil
+}
+
+// fetchReleases implements [Client.FetchPackageReleases] without applying the pin strategy.
+func (c *Client) fetchReleases(ctx context.Context, pkg string) (PackageReleases, error) {
 	if err := checkPackageName(pkg); err != nil {
-		return nil, fmt.Errorf("invalid package name: %w", err)
+		return PackageReleases{}, fmt.Errorf("invalid package name: %w", err)
+	}
+
+	var result PackageReleases
+	for _, repo := range c.Repositories.List {
+		if !repo.allows(pkg) || !c.RepositoryPolicy.permits(repo) {
+			continue
+		}
+		found, err := c.fetchRepositoryReleases(ctx, repo, pkg)
+		if errors.Is(err, errNotInRepository) {
+			continue
+		}
+		if err != nil {
+			return PackageReleases{}, fmt.Errorf("repository %s: %w", repo, err)
+		}
+		result = result.merge(found)
+		if repo.isCanonical() {
+			return result, nil
+		}
+	}
+
+	if _, ok := drupalModuleName(pkg); !ok && c.Repositories.PackagistDisabled {
+		if result.Releases == nil {
+			return PackageReleases{}, fmt.Errorf("%w: %s", errPackageNotFound, pkg)
+		}
+		return result, nil
+	}
+
+	project, err := c.fetchDrupalPackage(ctx, pkg)
+	found := project.packageReleases()
+	if errors.Is(err, errNotInRepository) {
+		found, err = c.fetchPackagistPackage(ctx, pkg)
+	}
+	if err != nil {
+		return PackageReleases{}, err
+	}
+	return result.merge(found), nil
+}
+
+// merge returns the releases of r and other merged using mergeReleases.
+// The project information of other takes precedence, if any.
+func (r PackageReleases) merge(other PackageReleases) PackageReleases {
+	r.Releases = mergeReleases(append(r.Releases, other.Releases...))
+	if other.Project != "" {
+		r.Project = other.Project
 	}
-	if name, ok := drupalModuleName(pkg); ok {
-		if isCorePackage(name) {
-			return c.FetchDrupalReleases(ctx, "drupal")
+	if other.Status != "" {
+		r.Status = other.Status
+		r.SupportedBranches = other.SupportedBranches
+	}
+	if other.Abandoned {
+		r.Abandoned, r.Replacement = true, other.Replacement
+	}
+	return r
+}
+
+// fetchDrupalPackage fetches the project of a drupal/* package from drupal.org.
+// With [DrupalSourceComposer], releases of modules are fetched from the drupal.org composer repository,
+// falling back to the release history if that fails.
+// Packages mapped in the Projects of c use the release history of their project,
+// and packages without a release history of their own use the one of the project found by [Client.ResolveDrupalProject].
+// It returns errNotInRepository for other packages.
+func (c *Client) fetchDrupalPackage(ctx context.Context, pkg string) (DrupalProject, error) {
+	name, ok := drupalModuleName(pkg)
+	if !ok {
+		return DrupalProject{}, errNotInRepository
+	}
+	if project, ok := c.Projects[pkg]; ok {
+		return c.FetchDrupalProject(ctx, project)
+	}
+	if isCorePackage(name) {
+		return c.FetchDrupalProject(ctx, "drupal")
+	}
+	if c.DrupalSource == DrupalSourceComposer {
+		releases, err := c.FetchDrupalComposerReleases(ctx, name)
+		if err == nil {
+			project, ok := drupalProjectOf(releases)
+			if !ok {
+				project = name
+			}
+			return DrupalProject{Name: project, Releases: releases}, nil
+		}
+	}
+
+	project, err := c.FetchDrupalProject(ctx, name)
+	if errors.Is(err, errDrupalProjectNotFound) || errors.Is(err, errHTTPNotFound) {
+		if parent, rerr := c.ResolveDrupalProject(ctx, pkg); rerr == nil && parent != name {
+			return c.FetchDrupalProject(ctx, parent)
 		}
-		return c.FetchDrupalReleases(ctx, name)
 	}
-	return c.FetchPackagistReleases(ctx, pkg)
+	return project, err
 }
 
 // fetchResponse fetches a response from a URL and parses it using a parser function.
-func fetchResponse[T any](ctx context.Context, client *Client, url string, parser func(io.Reader) (T, error)) (t T, e error) {
+// If client has a cache, the response is served from and stored in it, see fetchCached.
+func fetchResponse[T any](ctx context.Context, client *Client, url string, parser func(io.Reader) (T, error)) (T, error) {
+	if client.Cache != nil {
+		return fetchCached(ctx, client, url, parser)
+	}
+	return doRequest(ctx, client, url, nil, func(resp *http.Response) (t T, err error) {
+		if err := checkStatus(resp); err != nil {
+			return t, err
+		}
+		return parser(resp.Body)
+	})
+}
+
+// doRequest sends a GET request for a URL with the credentials of client, and handles the response using handle.
+// If prepare is not nil, it is called to modify the request before it is sent.
+func doRequest[T any](ctx context.Context, client *Client, url string, prepare func(*http.Request), handle func(*http.Response) (T, error)) (t T, e error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return t, fmt.Errorf("build request: %w", err)
 	}
+	client.Auth.authorize(req)
+	if prepare != nil {
+		prepare(req)
+	}
 	resp, err := client.HTTPClient.Do(req)
 	if err != nil {
 		return t, fmt.Errorf("request: %w", err)
@@ -99,11 +401,18 @@ func fetchResponse[T any](ctx context.Context, client *Client, url string, parse
 		e = errors.Join(e, err)
 	}()
 
+	return handle(resp)
+}
+
+// checkStatus returns an error if resp is not successful, wrapping errHTTPNotFound for missing resources.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return errHTTPNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return t, fmt.Errorf("%w: %d", errHTTPStatus, resp.StatusCode)
+		return fmt.Errorf("%w: %d", errHTTPStatus, resp.StatusCode)
 	}
-
-	return parser(resp.Body)
+	return nil
 }
 
 // sortReleases sorts releases by version in descending order (newest first).
@@ -1,29 +1,70 @@
 //spellchecker:words main
 package main
 
-//spellchecker:words flag http time github composer drupal update drupalupdate swaggest swgui
+//spellchecker:words errors flag http strings time github composer drupal update drupalupdate swaggest swgui
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
 	"github.com/swaggest/swgui/v5emb"
 )
 
+var errInvalidProject = errors.New("expected name=project")
+
 func main() {
 	addr := flag.String("addr", ":8080", "listen address")
+	cacheTTL := flag.Duration("cache-ttl", drupalupdate.DefaultCacheTTL, "age after which cached responses are revalidated with drupal.org, Packagist and other repositories")
+	noCache := flag.Bool("no-cache", false, "fetch all responses without using the on-disk cache")
+	drupalSource := flag.String("drupal-source", string(drupalupdate.DrupalSourceXML), "source of drupal module releases: xml (drupal.org release history) or composer (packages.drupal.org, falling back to xml)")
+	projects := make(map[string]string)
+	flag.Func("project", "drupal.org project of a drupal/* package not named after it as `name=project`, may be repeated", func(value string) error {
+		name, project, ok := strings.Cut(value, "=")
+		if !ok || project == "" {
+			return fmt.Errorf("%w: %q", errInvalidProject, value)
+		}
+		projects[name] = project
+		return nil
+	})
+	var repositoryHosts []string
+	flag.Func("repository-host", "host that composer repositories given in requests may be fetched from, \"*\" for any host, may be repeated; such repositories are ignored by default", func(value string) error {
+		repositoryHosts = append(repositoryHosts, value)
+		return nil
+	})
+	allowPrivate := flag.Bool("allow-private-repositories", false, "allow composer repositories given in requests on loopback, private and link-local addresses")
 	flag.Parse()
 
+	source, err := drupalupdate.ParseDrupalSource(*drupalSource)
+	if err != nil {
+		log.Fatalf("invalid -drupal-source: %v", err)
+	}
+
 	mux := http.NewServeMux()
 
 	// API routes
-	client := drupalupdate.NewClient()
+	client := drupalupdate.NewClient().WithDrupalSource(source).WithProjects(projects)
+	if !*noCache {
+		dir, err := drupalupdate.DefaultCacheDir()
+		if err != nil {
+			log.Fatalf("failed to find cache directory: %v", err)
+		}
+		client = client.WithCache(drupalupdate.NewDiskCache(dir), *cacheTTL)
+	}
 	api := drupalupdate.NewServer(client)
+	if len(repositoryHosts) > 0 {
+		api.RepositoryPolicy = drupalupdate.NewRepositoryPolicy(repositoryHosts, *allowPrivate)
+	}
 	mux.Handle("POST /api/parse", api)
 	mux.Handle("GET /api/releases", api)
+	mux.Handle("POST /api/releases", api)
+	mux.Handle("GET /api/changelog", api)
+	mux.Handle("GET /api/stale", api)
+	mux.Handle("GET /api/advisories", api)
 	mux.Handle("POST /api/update", api)
 
 	// Serve the OpenAPI spec
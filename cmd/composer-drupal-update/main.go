@@ -1,27 +1,109 @@
 //spellchecker:words main
 package main
 
-//spellchecker:words bufio context encoding json errors path filepath strings github composer drupal update drupalupdate
+//spellchecker:words bufio context errors flag path filepath slices strings time github composer drupal update drupalupdate
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: composer-drupal-update <path-to-composer.json>")
+	php := flag.String("php", "", "platform PHP version, defaults to config.platform.php of composer.json")
+	core := flag.String("core", "", "drupal core version to check modules against, defaults to the selected core release")
+	pin := flag.String("pin", string(drupalupdate.PinCaret), "pin strategy for new versions: caret, caret-patch, tilde, exact, minimum or keep")
+	maxUpdate := flag.String("max-update", "", "only offer updates up to this type: none, patch, minor, major or pre-release")
+	mode := flag.String("mode", string(drupalupdate.ModeLatest), "releases to offer: latest (per branch or major version) or all")
+	include := flag.String("include", "", "comma-separated stabilities additionally offered with -mode all, e.g. beta,dev")
+	stale := flag.String("stale", "", "only report packages whose latest or current release is older than this age, e.g. 1y or 180d")
+	drupalSource := flag.String("drupal-source", string(drupalupdate.DrupalSourceXML), "source of drupal module releases: xml (drupal.org release history) or composer (packages.drupal.org, falling back to xml)")
+	notes := flag.Bool("notes", false, "show the release notes up to the selected version and confirm the update")
+	cacheTTL := flag.Duration("cache-ttl", drupalupdate.DefaultCacheTTL, "age after which cached responses are revalidated with drupal.org, Packagist and other repositories")
+	noCache := flag.Bool("no-cache", false, "fetch all responses without using the on-disk cache")
+	clearCache := flag.Bool("clear-cache", false, "remove all cached responses before fetching")
+	pins := make(map[string]drupalupdate.PinStrategy)
+	flag.Func("pin-package", "pin strategy for a single package as `name=strategy`, may be repeated", func(value string) error {
+		name, strategy, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("%w: %q", errInvalidPinPackage, value)
+		}
+		parsed, err := drupalupdate.ParsePinStrategy(strategy)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		pins[name] = parsed
+		return nil
+	})
+	projects := make(map[string]string)
+	flag.Func("project", "drupal.org project of a drupal/* package not named after it as `name=project`, may be repeated", func(value string) error {
+		name, project, ok := strings.Cut(value, "=")
+		if !ok || project == "" {
+			return fmt.Errorf("%w: %q", errInvalidProject, value)
+		}
+		projects[name] = project
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Println("Usage: composer-drupal-update [flags] <path-to-composer.json>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	filePath := os.Args[1]
+	strategy, err := drupalupdate.ParsePinStrategy(*pin)
+	if err != nil {
+		fmt.Printf("Error reading -pin: %v\n", err)
+		os.Exit(1)
+	}
+
+	releaseMode, err := drupalupdate.ParseReleaseMode(*mode)
+	if err != nil {
+		fmt.Printf("Error reading -mode: %v\n", err)
+		os.Exit(1)
+	}
+	source, err := drupalupdate.ParseDrupalSource(*drupalSource)
+	if err != nil {
+		fmt.Printf("Error reading -drupal-source: %v\n", err)
+		os.Exit(1)
+	}
+	stabilities, err := drupalupdate.ParseStabilities(*include)
+	if err != nil {
+		fmt.Printf("Error reading -include: %v\n", err)
+		os.Exit(1)
+	}
+
+	var maxAge time.Duration
+	if *stale != "" {
+		maxAge, err = drupalupdate.ParseAge(*stale)
+		if err != nil {
+			fmt.Printf("Error reading -stale: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var limit drupalupdate.UpdateType
+	if *maxUpdate != "" {
+		limit, err = drupalupdate.ParseUpdateType(*maxUpdate)
+		if err != nil {
+			fmt.Printf("Error reading -max-update: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	filePath := flag.Arg(0)
 
 	composer, err := readComposerJSON(filePath)
 	if err != nil {
@@ -29,13 +111,85 @@ func main() {
 		os.Exit(1)
 	}
 
+	lock, err := readComposerLock(filepath.Join(filepath.Dir(filePath), "composer.lock"))
+	if err != nil {
+		fmt.Printf("Error reading composer.lock: %v\n", err)
+		os.Exit(1)
+	}
+
+	patches, err := readPatches(filepath.Dir(filePath), composer)
+	if err != nil {
+		fmt.Printf("Error reading patches: %v\n", err)
+		os.Exit(1)
+	}
+
+	repos, err := composer.Repositories()
+	if err != nil {
+		fmt.Printf("Error reading repositories: %v\n", err)
+		os.Exit(1)
+	}
+
+	stability, err := composer.StabilitySettings()
+	if err != nil {
+		fmt.Printf("Error reading stability settings: %v\n", err)
+		os.Exit(1)
+	}
+
+	auth, err := drupalupdate.LoadAuth(filepath.Dir(filePath))
+	if err != nil {
+		fmt.Printf("Error reading credentials: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *php == "" {
+		*php, err = composer.PlatformPHP()
+		if err != nil {
+			fmt.Printf("Error reading platform PHP version: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	client := drupalupdate.NewClient()
+	client.Repositories = repos
+	client.Stability = stability
+	client.PlatformPHP = *php
+	client.PinStrategy = strategy
+	client.Mode = releaseMode
+	client.Include = stabilities
+	client.DrupalSource = source
+	client.Projects = projects
+	client.Pins = pins
+	client.Auth = auth
+	if !*noCache {
+		client.Cache, client.CacheTTL = openCache(*clearCache), *cacheTTL
+	}
+	ctx := context.Background()
+
+	if maxAge > 0 {
+		reportStale(ctx, client, composer, lock, maxAge, *stale)
+		return
+	}
+
+	advisories, err := client.FetchAdvisories(ctx, packageNames(composer))
+	if err != nil {
+		fmt.Printf("Could not fetch security advisories: %v\n", err)
+	}
+
+	// Fetch the releases of all packages at once
+	corePkgs := composer.CorePackages()
+	drupalPkgs := composer.DrupalPackages()
+	composerPkgs := composer.ComposerPackages()
+	for _, pkgs := range [][]drupalupdate.Package{corePkgs, drupalPkgs, composerPkgs} {
+		lock.Annotate(pkgs)
+		patches.Annotate(pkgs)
+	}
+	results := fetchAll(ctx, client, slices.Concat(corePkgs, drupalPkgs, composerPkgs))
+
 	reader := bufio.NewReader(os.Stdin)
 	changed := false
-	ctx := context.Background()
+	var patched []string // updated packages that carry patches
 
 	// Process Drupal Core
-	corePkgs := composer.CorePackages()
 	if len(corePkgs) > 0 {
 		fmt.Println("\n=== Drupal Core ===")
 		fmt.Print("  Packages: ")
@@ -43,19 +197,32 @@ func main() {
 			if i > 0 {
 				fmt.Print(", ")
 			}
-			fmt.Print(pkg.Name)
+			fmt.Print(packageLabel(pkg))
 		}
 		fmt.Println()
 
-		releases, err := client.FetchDrupalReleases(ctx, "drupal")
+		result := results[0]
+		found, err := result.Releases, result.Err
+		releases := found.Releases
+		if err == nil {
+			warnProject("Drupal Core", corePkgs[0], found)
+		}
 		switch {
 		case err != nil:
 			fmt.Printf("  Could not fetch core releases: %v\n", err)
 		case len(releases) > 0:
-			newVersion := selectVersion(reader, "Drupal Core", corePkgs[0].Version, releases)
+			var coreAdvisories []drupalupdate.Advisory
+			for _, pkg := range corePkgs {
+				coreAdvisories = append(coreAdvisories, advisories[pkg.Name]...)
+			}
+			newVersion := selectVersion(reader, "Drupal Core", corePkgs[0], releases, coreAdvisories, limit, changelogFunc(ctx, client.ForPackage(corePkgs[0]), corePkgs[0], *notes))
+			if *core == "" {
+				*core = selectedRelease(releases, newVersion)
+			}
 			if newVersion != "" && newVersion != corePkgs[0].Version {
 				for _, pkg := range corePkgs {
-					composer.Require[pkg.Name] = newVersion
+					composer.SetVersion(pkg.Section, pkg.Name, newVersion)
+					patched = append(patched, warnPatches(pkg)...)
 				}
 				changed = true
 			}
@@ -63,48 +230,66 @@ func main() {
 			fmt.Println("  No releases found")
 		}
 	}
+	results = results[len(corePkgs):]
 
 	// Process Drupal packages
-	drupalPkgs := composer.DrupalPackages()
 	if len(drupalPkgs) > 0 {
 		fmt.Println("\n=== Drupal Packages ===")
-		for _, pkg := range drupalPkgs {
-			releases, err := client.FetchDrupalReleases(ctx, pkg.Module)
-			if err != nil {
-				fmt.Printf("  [%s] Could not fetch releases: %v\n", pkg.Name, err)
+		if *core != "" {
+			fmt.Printf("  Checking compatibility with Drupal core %s\n", *core)
+		}
+		for _, result := range results[:len(drupalPkgs)] {
+			pkg, found := result.Package, result.Releases
+			if found.Project != "" {
+				pkg.Module = found.Project
+			}
+			if result.Err != nil {
+				fmt.Printf("  [%s] Could not fetch releases: %v\n", packageLabel(pkg), result.Err)
 				continue
 			}
+			warnProject(packageLabel(pkg), pkg, found)
+			releases := found.Releases
 			if len(releases) == 0 {
-				fmt.Printf("  [%s] No releases found\n", pkg.Name)
+				fmt.Printf("  [%s] No releases found\n", packageLabel(pkg))
 				continue
 			}
 
-			newVersion := selectVersion(reader, pkg.Name, pkg.Version, releases)
+			drupalupdate.MarkCoreCompatibility(releases, *core)
+			newVersion := selectVersion(reader, packageLabel(pkg), pkg, releases, advisories[pkg.Name], limit, changelogFunc(ctx, client.ForPackage(pkg), pkg, *notes))
 			if newVersion != "" && newVersion != pkg.Version {
-				composer.Require[pkg.Name] = newVersion
+				composer.SetVersion(pkg.Section, pkg.Name, newVersion)
+				patched = append(patched, warnPatches(pkg)...)
 				changed = true
 			}
 		}
 	}
+	results = results[len(drupalPkgs):]
 
 	// Process Composer (non-Drupal) packages
-	composerPkgs := composer.ComposerPackages()
 	if len(composerPkgs) > 0 {
 		fmt.Println("\n=== Composer Packages ===")
-		for _, pkg := range composerPkgs {
-			releases, err := client.FetchPackagistReleases(ctx, pkg.Name)
-			if err != nil {
-				fmt.Printf("  [%s] Could not fetch releases: %v\n", pkg.Name, err)
+		for _, result := range results {
+			pkg, found := result.Package, result.Releases
+			if result.Err != nil {
+				fmt.Printf("  [%s] Could not fetch releases: %v\n", packageLabel(pkg), result.Err)
+				continue
+			}
+			warnProject(packageLabel(pkg), pkg, found)
+			pkgClient := client.ForPackage(pkg)
+			if replaceAbandoned(ctx, reader, pkgClient, composer, pkg, found) {
+				changed = true
 				continue
 			}
+			releases := found.Releases
 			if len(releases) == 0 {
-				fmt.Printf("  [%s] No releases found\n", pkg.Name)
+				fmt.Printf("  [%s] No releases found\n", packageLabel(pkg))
 				continue
 			}
 
-			newVersion := selectVersion(reader, pkg.Name, pkg.Version, releases)
+			newVersion := selectVersion(reader, packageLabel(pkg), pkg, releases, advisories[pkg.Name], limit, changelogFunc(ctx, pkgClient, pkg, *notes))
 			if newVersion != "" && newVersion != pkg.Version {
-				composer.Require[pkg.Name] = newVersion
+				composer.SetVersion(pkg.Section, pkg.Name, newVersion)
+				patched = append(patched, warnPatches(pkg)...)
 				changed = true
 			}
 		}
@@ -116,22 +301,211 @@ func main() {
 			os.Exit(1)
 		}
 		fmt.Println("\ncomposer.json updated successfully!")
+		if len(patched) > 0 {
+			fmt.Printf("Review the patches of: %s\n", strings.Join(patched, ", "))
+		}
 	} else {
 		fmt.Println("\nNo changes made.")
 	}
 }
 
-func selectVersion(reader *bufio.Reader, packageName, currentVersion string, releases []drupalupdate.Release) string {
-	fmt.Printf("\n%s (current: %s)\n", packageName, currentVersion)
+// openCache opens the on-disk cache in the default cache directory, removing all its entries if clear is set.
+// It returns nil, disabling the cache, if there is no cache directory or it cannot be cleared.
+func openCache(clear bool) drupalupdate.Cache {
+	dir, err := drupalupdate.DefaultCacheDir()
+	if err != nil {
+		fmt.Printf("Not caching responses: %v\n", err)
+		return nil
+	}
+	cache := drupalupdate.NewDiskCache(dir)
+	if clear {
+		if err := cache.Clear(); err != nil {
+			fmt.Printf("Not caching responses: %v\n", err)
+			return nil
+		}
+	}
+	return cache
+}
+
+// fetchAll fetches the releases of all pkgs concurrently, reporting the progress on a single line.
+func fetchAll(ctx context.Context, client *drupalupdate.Client, pkgs []drupalupdate.Package) []drupalupdate.PackageResult {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	results := client.WithProgress(func(done, total int, pkg drupalupdate.Package) {
+		fmt.Printf("\rFetching releases... %d/%d", done, total)
+	}).FetchAll(ctx, pkgs)
+	fmt.Println()
+	return results
+}
+
+// reportStale prints the packages of composer whose latest or current release is older than maxAge.
+// Drupal core is checked once, using its first package.
+func reportStale(ctx context.Context, client *drupalupdate.Client, composer *drupalupdate.ComposerJSON, lock drupalupdate.ComposerLock, maxAge time.Duration, label string) {
+	var pkgs []drupalupdate.Package
+	if core := composer.CorePackages(); len(core) > 0 {
+		pkgs = append(pkgs, core[0])
+	}
+	pkgs = append(pkgs, composer.DrupalPackages()...)
+	pkgs = append(pkgs, composer.ComposerPackages()...)
+	lock.Annotate(pkgs)
+
+	// consider all releases, including pre-releases, like [drupalupdate.Client.FetchStaleness]
+	results := fetchAll(ctx, client.WithMode(drupalupdate.ModeAll, drupalupdate.StabilityRC, drupalupdate.StabilityBeta, drupalupdate.StabilityAlpha), pkgs)
+	now := time.Now()
+
+	fmt.Printf("\n=== Packages without releases in %s ===\n", label)
+	found := false
+	for _, result := range results {
+		pkg := result.Package
+		if result.Err != nil {
+			fmt.Printf("  [%s] Could not fetch releases: %v\n", packageLabel(pkg), result.Err)
+			continue
+		}
+		staleness := drupalupdate.CheckStaleness(pkg, result.Releases.Releases, maxAge, now)
+		if !staleness.Stale && !staleness.CurrentStale {
+			continue
+		}
+		found = true
+
+		details := "latest " + staleness.Latest + " released " + staleness.LatestDate.Format(time.DateOnly)
+		if staleness.Stale {
+			details += ", package may be unmaintained"
+		}
+		if staleness.CurrentStale && staleness.Current != staleness.Latest {
+			details += "; current " + staleness.Current + " released " + staleness.CurrentDate.Format(time.DateOnly)
+		}
+		fmt.Printf("  %-40s %s\n", packageLabel(pkg), details)
+	}
+	if !found {
+		fmt.Println("  No stale packages")
+	}
+}
+
+// changelogFunc returns a function that fetches the release notes of pkg up to a release, formatted for display.
+// Notes start after the installed version of pkg, or the lower bound of its constraint if it is not installed.
+// It returns nil if release notes are disabled.
+func changelogFunc(ctx context.Context, client *drupalupdate.Client, pkg drupalupdate.Package, enabled bool) func(drupalupdate.Release) string {
+	if !enabled {
+		return nil
+	}
+	from := pkg.Installed
+	if from == "" {
+		from = pkg.Version
+	}
+	return func(release drupalupdate.Release) string {
+		changelog, err := client.FetchChangelog(ctx, pkg.Name, from, release.Version)
+		if err != nil {
+			return fmt.Sprintf("Could not fetch release notes: %v\n", err)
+		}
+		if len(changelog) == 0 {
+			return "No releases since the current version\n"
+		}
+		return drupalupdate.FormatChangelog(changelog)
+	}
+}
+
+// packageNames returns the names of all updatable packages of composer.
+func packageNames(composer *drupalupdate.ComposerJSON) []string {
+	var names []string
+	for _, pkgs := range [][]drupalupdate.Package{composer.CorePackages(), composer.DrupalPackages(), composer.ComposerPackages()} {
+		for _, pkg := range pkgs {
+			names = append(names, pkg.Name)
+		}
+	}
+	return names
+}
+
+// packageLabel returns the name of a package for display, marking development dependencies
+// and drupal/* packages provided by a differently named drupal.org project.
+func packageLabel(pkg drupalupdate.Package) string {
+	label := pkg.Name
+	if project, ok := strings.CutPrefix(pkg.Name, "drupal/"); ok && pkg.Module != "" && pkg.Module != project {
+		label += " (" + pkg.Module + ")"
+	}
+	if pkg.Section == drupalupdate.SectionRequireDev {
+		label += " (dev)"
+	}
+	return label
+}
+
+// advisoryLabel returns a security advisory for display, e.g. "CVE-2024-1234: title (affected: <1.2.3, https://...)".
+func advisoryLabel(advisory drupalupdate.Advisory) string {
+	label := advisory.Title
+	if advisory.CVE != "" {
+		label = advisory.CVE + ": " + label
+	}
+	label += " (affected: " + advisory.AffectedVersions
+	if advisory.Link != "" {
+		label += ", " + advisory.Link
+	}
+	return label + ")"
+}
+
+// selectVersion lets the user pick one of releases for pkg, and returns the version pin of the selected release.
+// Security advisories of pkg are shown for its current version and each release they affect.
+// If limit is not empty, only releases up to that update type are offered.
+// If changelog is not nil, the release notes it returns are shown and the update has to be confirmed.
+// It returns "" if the current version should be kept.
+func selectVersion(reader *bufio.Reader, packageName string, pkg drupalupdate.Package, releases []drupalupdate.Release, advisories []drupalupdate.Advisory, limit drupalupdate.UpdateType, changelog func(drupalupdate.Release) string) string {
+	if pkg.Installed != "" {
+		fmt.Printf("\n%s (current: %s, installed: %s)\n", packageName, pkg.Version, pkg.Installed)
+	} else {
+		fmt.Printf("\n%s (current: %s)\n", packageName, pkg.Version)
+	}
+	if len(pkg.Patches) > 0 {
+		fmt.Printf("  patched: %d patch(es)\n", len(pkg.Patches))
+	}
 	fmt.Println(strings.Repeat("-", 60))
 
+	drupalupdate.MarkInstalled(releases, pkg.Installed)
+	drupalupdate.MarkAllowed(releases, pkg.Version)
+	drupalupdate.MarkUpdateType(releases, pkg.Version)
+	fmt.Printf("  %s\n", drupalupdate.SummarizeUpdates(releases))
+	if security := drupalupdate.SecurityUpdate(releases, pkg.Version); security != "" {
+		fmt.Printf("  ! SECURITY: %s allows versions below security release %s\n", pkg.Version, security)
+	}
+	drupalupdate.MarkAdvisories(releases, advisories)
+	for _, advisory := range drupalupdate.CurrentAdvisories(advisories, pkg.Version, pkg.Installed) {
+		fmt.Printf("  ! ADVISORY: %s\n", advisoryLabel(advisory))
+	}
+	if limit != "" {
+		releases = drupalupdate.FilterUpdates(releases, limit)
+		if len(releases) == 0 {
+			fmt.Printf("  No releases up to a %s update\n", limit)
+			return ""
+		}
+	}
 	for i, r := range releases {
-		coreCompat := r.CoreCompatibility
-		if coreCompat != "" {
-			fmt.Printf("  [%d] %-12s (%s, core: %s)\n", i+1, r.VersionPin, r.Version, coreCompat)
-		} else {
-			fmt.Printf("  [%d] %-12s (%s)\n", i+1, r.VersionPin, r.Version)
+		details := r.Version
+		if r.CoreCompatibility != "" {
+			details += ", core: " + r.CoreCompatibility
+		}
+		if r.PHP != "" {
+			details += ", php: " + r.PHP
+		}
+		if !r.Date.IsZero() {
+			details += ", released " + r.Date.Format(time.DateOnly)
+		}
+		if r.Relation != "" {
+			details += ", " + string(r.Relation)
+		}
+		if r.Update != "" && r.Update != drupalupdate.UpdateNone {
+			details += ", " + string(r.Update) + " update"
+		}
+		if r.Security {
+			details += ", security update"
 		}
+		if len(r.Advisories) > 0 {
+			details += fmt.Sprintf(", %d advisory(ies)", len(r.Advisories))
+		}
+		if r.Allowed {
+			details += ", already allowed"
+		}
+		if r.CoreIncompatible {
+			details += ", incompatible with targeted core"
+		}
+		fmt.Printf("  [%d] %-12s (%s)\n", i+1, r.VersionPin, details)
 	}
 	fmt.Println("  [s] Skip (keep current version)")
 	fmt.Println()
@@ -150,6 +524,10 @@ func selectVersion(reader *bufio.Reader, packageName, currentVersion string, rel
 		if _, err := fmt.Sscanf(input, "%d", &choice); err == nil {
 			if choice >= 1 && choice <= len(releases) {
 				newVersion := releases[choice-1].VersionPin
+				if changelog != nil && !confirmUpdate(reader, newVersion, changelog(releases[choice-1])) {
+					fmt.Println("  -> Not updated, select another version or skip")
+					continue
+				}
 				fmt.Printf("  -> Updated to %s\n", newVersion)
 				return newVersion
 			}
@@ -159,27 +537,53 @@ func selectVersion(reader *bufio.Reader, packageName, currentVersion string, rel
 	}
 }
 
-var errInvalidPath = errors.New("invalid path")
+// confirmUpdate shows the release notes up to a version and asks whether to update to it.
+func confirmUpdate(reader *bufio.Reader, version string, notes string) bool {
+	fmt.Println()
+	for line := range strings.Lines(notes) {
+		if strings.TrimSpace(line) == "" {
+			fmt.Println()
+			continue
+		}
+		fmt.Print("    " + line)
+	}
+	fmt.Println()
+	fmt.Printf("Update to %s? [Y/n]: ", version)
+	input, _ := reader.ReadString('\n')
+	input = strings.ToLower(strings.TrimSpace(input))
+	return input == "" || input == "y" || input == "yes"
+}
+
+// selectedRelease returns the version of the release with the given version pin,
+// or "" if no release was selected.
+func selectedRelease(releases []drupalupdate.Release, pin string) string {
+	for _, r := range releases {
+		if r.VersionPin == pin {
+			return r.Version
+		}
+	}
+	return ""
+}
+
+var (
+	errInvalidPath       = errors.New("invalid path")
+	errInvalidPinPackage = errors.New("expected name=strategy")
+	errInvalidProject    = errors.New("expected name=project")
+)
 
 // readComposerJSON reads a composer.json file from the given path.
-func readComposerJSON(path string) (c *drupalupdate.ComposerJSON, e error) {
+func readComposerJSON(path string) (*drupalupdate.ComposerJSON, error) {
 	path = filepath.Clean(path)
 	if path == "" || path == "." || strings.Contains(path, "..") {
 		return nil, fmt.Errorf("%w: %s", errInvalidPath, path)
 	}
 
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("open %s: %w", path, err)
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			e = errors.Join(e, err)
-		}
-	}()
 
-	var composer drupalupdate.ComposerJSON
-	err = json.NewDecoder(file).Decode(&composer)
+	composer, err := drupalupdate.ParseComposerJSON(data)
 	if err != nil {
 		return nil, fmt.Errorf("read %s: %w", path, err)
 	}
@@ -187,12 +591,18 @@ func readComposerJSON(path string) (c *drupalupdate.ComposerJSON, e error) {
 }
 
 // writeComposerJSON writes a composer.json file to the given path.
+// The original formatting of the file is preserved.
 func writeComposerJSON(path string, composer *drupalupdate.ComposerJSON) (e error) {
 	path = filepath.Clean(path)
 	if path == "" || path == "." || strings.Contains(path, "..") {
 		return fmt.Errorf("%w: %s", errInvalidPath, path)
 	}
 
+	data, err := composer.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", path, err)
+	}
+
 	file, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("create %s: %w", path, err)
@@ -203,9 +613,125 @@ func writeComposerJSON(path string, composer *drupalupdate.ComposerJSON) (e erro
 		}
 	}()
 
-	err = json.NewEncoder(file).Encode(composer)
+	_, err = file.Write(data)
 	if err != nil {
 		return fmt.Errorf("write %s: %w", path, err)
 	}
 	return nil
 }
+
+// warnProject prints a warning if the drupal.org project of pkg is not published,
+// or if the current constraint of pkg is not on a supported branch.
+func warnProject(packageName string, pkg drupalupdate.Package, found drupalupdate.PackageReleases) {
+	if warning := found.Status.Warning(); warning != "" {
+		fmt.Printf("  ! [%s] WARNING: %s\n", packageName, warning)
+	}
+	if drupalupdate.BranchUnsupported(pkg.Version, found.SupportedBranches) {
+		fmt.Printf("  ! [%s] WARNING: %s is not on a supported branch (%s)\n", packageName, pkg.Version, strings.Join(found.SupportedBranches, ", "))
+	}
+	if abandoned, replacement := abandonment(pkg, found); abandoned {
+		if replacement != "" {
+			fmt.Printf("  ! [%s] WARNING: package is abandoned, use %s instead\n", packageName, replacement)
+		} else {
+			fmt.Printf("  ! [%s] WARNING: package is abandoned\n", packageName)
+		}
+	}
+}
+
+// abandonment reports whether pkg is abandoned according to its metadata or composer.lock,
+// and the suggested replacement, if any.
+func abandonment(pkg drupalupdate.Package, found drupalupdate.PackageReleases) (abandoned bool, replacement string) {
+	if found.Abandoned {
+		return true, found.Replacement
+	}
+	return pkg.Abandoned, pkg.Replacement
+}
+
+// replaceAbandoned asks whether to replace an abandoned package by its suggested replacement,
+// which is required with the version pin of its newest release.
+// The package is kept if no release of the replacement can be found.
+// It returns true if the package was replaced.
+func replaceAbandoned(ctx context.Context, reader *bufio.Reader, client *drupalupdate.Client, composer *drupalupdate.ComposerJSON, pkg drupalupdate.Package, found drupalupdate.PackageReleases) bool {
+	_, replacement := abandonment(pkg, found)
+	if replacement == "" {
+		return false
+	}
+
+	fmt.Printf("\nReplace %s with %s? [y/N]: ", pkg.Name, replacement)
+	input, _ := reader.ReadString('\n')
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input != "y" && input != "yes" {
+		return false
+	}
+
+	releases, err := client.FetchReleases(ctx, replacement)
+	if err != nil {
+		fmt.Printf("  Could not fetch releases of %s, keeping %s: %v\n", replacement, pkg.Name, err)
+		return false
+	}
+	if len(releases) == 0 {
+		fmt.Printf("  No release of %s found, keeping %s\n", replacement, pkg.Name)
+		return false
+	}
+	version := releases[0].VersionPin
+	if !composer.ReplacePackage(pkg.Name, replacement, version) {
+		return false
+	}
+	fmt.Printf("  -> Replaced by %s %s\n", replacement, version)
+	return true
+}
+
+// warnPatches prints a warning if pkg carries patches, which may no longer apply after an update.
+// It returns the package name if it has patches.
+func warnPatches(pkg drupalupdate.Package) []string {
+	if len(pkg.Patches) == 0 {
+		return nil
+	}
+	fmt.Printf("  ! %s has %d patch(es) that may no longer apply:\n", pkg.Name, len(pkg.Patches))
+	for _, patch := range pkg.Patches {
+		fmt.Printf("      - %s (%s)\n", patch.Description, patch.URL)
+	}
+	return []string{pkg.Name}
+}
+
+// readPatches reads the composer-patches definitions of composer,
+// including an external patches file relative to dir.
+func readPatches(dir string, composer *drupalupdate.ComposerJSON) (drupalupdate.Patches, error) {
+	patches, err := composer.Patches()
+	if err != nil {
+		return nil, fmt.Errorf("read patches: %w", err)
+	}
+
+	file := composer.PatchesFile()
+	if file == "" {
+		return patches, nil
+	}
+	path := filepath.Join(dir, filepath.Clean(file))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	external, err := drupalupdate.ParsePatchesFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return patches.Merge(external), nil
+}
+
+// readComposerLock reads a composer.lock file from the given path.
+// A missing file is not an error and results in an empty lock.
+func readComposerLock(path string) (drupalupdate.ComposerLock, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return drupalupdate.ComposerLock{}, nil
+	}
+	if err != nil {
+		return drupalupdate.ComposerLock{}, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	lock, err := drupalupdate.ParseComposerLock(data)
+	if err != nil {
+		return drupalupdate.ComposerLock{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	return lock, nil
+}
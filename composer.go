@@ -1,60 +1,164 @@
 //spellchecker:words drupalupdate
 package drupalupdate
 
-//spellchecker:words encoding json maps sort strings
+//spellchecker:words bytes encoding json sort strings
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"maps"
 	"sort"
 	"strings"
 )
 
 // ComposerJSON represents the structure of a composer.json file.
 type ComposerJSON struct {
-	Require map[string]string // required dependencies, set to nil to remove dependencies entirely.
+	Require    map[string]string // required dependencies, set to nil to remove dependencies entirely.
+	RequireDev map[string]string // development dependencies, set to nil to remove dependencies entirely.
 
 	Raw map[string]json.RawMessage // original JSON, for round-trip on extra fields
+
+	source  []byte // original source, used to preserve formatting when marshaling
+	trailer []byte // whitespace following the original source, nil if unknown
 }
 
+// ParseComposerJSON parses the contents of a composer.json file.
+// In addition to [json.Unmarshal], it remembers the whitespace at the end of the file
+// so that [ComposerJSON.MarshalJSON] reproduces unchanged files byte for byte.
+func ParseComposerJSON(data []byte) (ComposerJSON, error) {
+	var c ComposerJSON
+	if err := json.Unmarshal(data, &c); err != nil {
+		return ComposerJSON{}, fmt.Errorf("parse composer.json: %w", err)
+	}
+	trimmed := bytes.TrimRight(data, " \t\r\n")
+	c.trailer = append([]byte{}, data[len(trimmed):]...)
+	return c, nil
+}
+
+// Section identifies a dependency section of a composer.json file.
+type Section string
+
+const (
+	SectionRequire    Section = "require"     // the "require" section
+	SectionRequireDev Section = "require-dev" // the "require-dev" section
+)
+
+// sections lists all dependency sections in the order they are processed.
+var sections = []Section{SectionRequire, SectionRequireDev}
+
 // UnmarshalJSON implements json.Unmarshaler for ComposerJSON.
 func (c *ComposerJSON) UnmarshalJSON(data []byte) error {
 	// First unmarshal everything into the raw map.
-	// and then extract the 'require' key if it exists.
+	// and then extract the dependency sections if they exist.
 	if err := json.Unmarshal(data, &c.Raw); err != nil {
 		return fmt.Errorf("composerJSON must be a map: %w", err)
 	}
-	require, ok := c.Raw["require"]
-	if !ok {
-		return nil
-	}
-	if err := json.Unmarshal(require, &c.Require); err != nil {
-		return fmt.Errorf("failed to unmarshal require key: %w", err)
+	c.source = bytes.Clone(data)
+	c.trailer = nil
+	for _, section := range sections {
+		raw, ok := c.Raw[string(section)]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, c.section(section)); err != nil {
+			return fmt.Errorf("failed to unmarshal %s key: %w", section, err)
+		}
 	}
 	return nil
 }
 
 // MarshalJSON implements json.Marshaler for ComposerJSON.
-// It preserves all original fields and only updates "require".
+// It preserves all original fields and only updates "require" and "require-dev".
+//
+// When c was unmarshaled from JSON, the original key order, indentation and escaping
+// are kept and only the bytes of changed constraints are rewritten.
+// Note that encoding/json compacts the output of MarshalJSON when c is nested in
+// another value; call MarshalJSON directly to keep the formatting.
 func (c ComposerJSON) MarshalJSON() ([]byte, error) {
-	original := maps.Clone(c.Raw)
-
-	// Re-marshal the require key unless it is nil!
-	if c.Require != nil {
-		var err error
-		original["require"], err = json.Marshal(c.Require)
+	if c.source != nil {
+		output, err := c.marshalPreserving()
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal require key: %w", err)
+			return nil, fmt.Errorf("marshal: %w", err)
+		}
+		if c.trailer == nil {
+			return append(output, '\n'), nil
+		}
+		return append(output, c.trailer...), nil
+	}
+
+	original := make(map[string]any, len(c.Raw)+len(sections))
+	for key, value := range c.Raw {
+		original[key] = value
+	}
+
+	// Replace each section unless it is nil!
+	for _, section := range sections {
+		deps := *c.section(section)
+		if deps == nil {
+			delete(original, string(section))
+			continue
 		}
-	} else {
-		delete(original, "require")
+		original[string(section)] = deps
 	}
 
-	output, err := json.MarshalIndent(original, "", "    ")
-	if err != nil {
+	var output bytes.Buffer
+	enc := json.NewEncoder(&output)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(original); err != nil {
 		return nil, fmt.Errorf("marshal: %w", err)
 	}
-	return append(output, '\n'), nil
+	return output.Bytes(), nil
+}
+
+// section returns a pointer to the dependency map for the given section.
+func (c *ComposerJSON) section(section Section) *map[string]string {
+	if section == SectionRequireDev {
+		return &c.RequireDev
+	}
+	return &c.Require
+}
+
+// SetVersion sets the version constraint of a package in the given section.
+// It returns false and leaves c unchanged if the package is not present in that section.
+func (c *ComposerJSON) SetVersion(section Section, name, version string) bool {
+	deps := *c.section(section)
+	if _, ok := deps[name]; !ok {
+		return false
+	}
+	deps[name] = version
+	return true
+}
+
+// UpdateVersion sets the version constraint of a package in every section it is present in.
+// It returns false if the package is not present in any section.
+func (c *ComposerJSON) UpdateVersion(name, version string) bool {
+	updated := false
+	for _, section := range sections {
+		if c.SetVersion(section, name, version) {
+			updated = true
+		}
+	}
+	return updated
+}
+
+// ReplacePackage replaces a package by another one in every section it is present in,
+// requiring the replacement with the given version constraint.
+// A replacement already present in a section keeps its constraint.
+// It returns false and leaves c unchanged if the package is not present in any section.
+func (c *ComposerJSON) ReplacePackage(name, replacement, version string) bool {
+	replaced := false
+	for _, section := range sections {
+		deps := *c.section(section)
+		if _, ok := deps[name]; !ok {
+			continue
+		}
+		delete(deps, name)
+		if _, ok := deps[replacement]; !ok {
+			deps[replacement] = version
+		}
+		replaced = true
+	}
+	return replaced
 }
 
 // =============================================================================
@@ -63,23 +167,35 @@ func (c ComposerJSON) MarshalJSON() ([]byte, error) {
 
 // Package represents a composer package found in composer.json.
 type Package struct {
-	Name    string `json:"name"`    // composer package name, e.g. "drupal/gin" or "drush/drush"
-	Module  string `json:"module"`  // identifier for fetching releases (drupal module name or full package name)
-	Version string `json:"version"` // current version constraint, e.g. "^5.0"
+	Name    string  `json:"name"`    // composer package name, e.g. "drupal/gin" or "drush/drush"
+	Module  string  `json:"module"`  // drupal module name, or full package name of other packages
+	Version string  `json:"version"` // current version constraint, e.g. "^5.0"
+	Section Section `json:"section"` // section the package was found in, e.g. "require" or "require-dev"
+
+	Installed string `json:"installed,omitempty"` // exact version from composer.lock, e.g. "5.0.3"
+	Reference string `json:"reference,omitempty"` // source reference from composer.lock, e.g. a commit hash
+
+	Abandoned   bool   `json:"abandoned,omitempty"`   // the package is abandoned according to composer.lock
+	Replacement string `json:"replacement,omitempty"` // package suggested to replace the abandoned package, if any
+
+	Patches []Patch `json:"patches,omitempty"` // patches applied by composer-patches
 }
 
-// filterPackages iterates over c.Require and collects packages that match the filter.
+// filterPackages iterates over all sections and collects packages that match the filter.
 // The filter function should return the module name and true if the package should be included.
+// Packages are sorted by name, with "require" entries before "require-dev" entries of the same name.
 func (c *ComposerJSON) filterPackages(filter func(name, version string) (module string, include bool)) []Package {
 	var pkgs []Package
-	for name, version := range c.Require {
-		module, include := filter(name, version)
-		if !include {
-			continue
+	for _, section := range sections {
+		for name, version := range *c.section(section) {
+			module, include := filter(name, version)
+			if !include {
+				continue
+			}
+			pkgs = append(pkgs, Package{Name: name, Module: module, Version: version, Section: section})
 		}
-		pkgs = append(pkgs, Package{Name: name, Module: module, Version: version})
 	}
-	sort.Slice(pkgs, func(i, j int) bool {
+	sort.SliceStable(pkgs, func(i, j int) bool {
 		return pkgs[i].Name < pkgs[j].Name
 	})
 	return pkgs
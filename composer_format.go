@@ -0,0 +1,440 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words bytes encoding json errors slices strings
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// =============================================================================
+// Formatting-preserving composer.json writer
+// =============================================================================
+
+// errMalformedJSON is returned when the original source of a composer.json cannot be scanned.
+var errMalformedJSON = errors.New("malformed JSON")
+
+// marshalPreserving re-serializes c by editing its original source in place.
+// Top-level keys keep their original order and formatting, and only the bytes of
+// values that actually changed are rewritten.
+func (c ComposerJSON) marshalPreserving() ([]byte, error) {
+	doc := jsonDocument{data: c.source}
+	root, err := doc.scanRoot()
+	if err != nil {
+		return nil, err
+	}
+	doc.layout = detectLayout(c.source, root)
+
+	var edits []memberEdit
+	seen := make(map[string]bool, len(root.members))
+	for i, member := range root.members {
+		if seen[member.key] {
+			edits = append(edits, memberEdit{orig: i, key: member.key})
+			continue
+		}
+		seen[member.key] = true
+
+		if section, ok := sectionByKey(member.key); ok {
+			deps := *c.section(section)
+			if deps == nil {
+				continue
+			}
+			value, err := doc.rewriteDependencies(member, deps)
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, memberEdit{orig: i, key: member.key, value: value})
+			continue
+		}
+
+		raw, ok := c.Raw[member.key]
+		if !ok {
+			continue
+		}
+		edit := memberEdit{orig: i, key: member.key}
+		if !bytes.Equal(raw, doc.value(member)) {
+			edit.value = raw
+		}
+		edits = append(edits, edit)
+	}
+
+	// Append any keys that were not present in the original document.
+	var added []string
+	for key := range c.Raw {
+		if _, isSection := sectionByKey(key); !isSection && !seen[key] {
+			added = append(added, key)
+		}
+	}
+	slices.Sort(added)
+	for _, key := range added {
+		edits = append(edits, memberEdit{orig: -1, key: key, value: c.Raw[key]})
+	}
+	for _, section := range sections {
+		deps := *c.section(section)
+		if deps == nil || seen[string(section)] {
+			continue
+		}
+		edits = append(edits, memberEdit{orig: -1, key: string(section), value: doc.renderDependencies(deps, 1)})
+	}
+
+	var buf bytes.Buffer
+	doc.writeObject(&buf, root, edits, 0)
+	return buf.Bytes(), nil
+}
+
+// sectionByKey returns the section with the given top-level key, if any.
+func sectionByKey(key string) (Section, bool) {
+	for _, section := range sections {
+		if string(section) == key {
+			return section, true
+		}
+	}
+	return "", false
+}
+
+// rewriteDependencies returns the new value of a dependency section member.
+// Constraints that did not change keep their original bytes.
+func (d *jsonDocument) rewriteDependencies(member jsonMember, deps map[string]string) ([]byte, error) {
+	if d.data[member.valueStart] != '{' {
+		return d.renderDependencies(deps, 1), nil
+	}
+
+	d.pos = member.valueStart
+	obj, err := d.scanObject()
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []memberEdit
+	seen := make(map[string]bool, len(obj.members))
+	for i, entry := range obj.members {
+		version, ok := deps[entry.key]
+		if !ok || seen[entry.key] {
+			continue
+		}
+		seen[entry.key] = true
+
+		edit := memberEdit{orig: i, key: entry.key}
+		var original string
+		if err := json.Unmarshal(d.value(entry), &original); err != nil || original != version {
+			edit.value = encodeJSONString(version)
+		}
+		edits = append(edits, edit)
+	}
+
+	var added []string
+	for name := range deps {
+		if !seen[name] {
+			added = append(added, name)
+		}
+	}
+	slices.Sort(added)
+	for _, name := range added {
+		edits = append(edits, memberEdit{orig: -1, key: name, value: encodeJSONString(deps[name])})
+	}
+
+	var buf bytes.Buffer
+	d.writeObject(&buf, obj, edits, 1)
+	return buf.Bytes(), nil
+}
+
+// renderDependencies renders a dependency map as a new JSON object at the given depth.
+func (d *jsonDocument) renderDependencies(deps map[string]string, depth int) []byte {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	edits := make([]memberEdit, len(names))
+	for i, name := range names {
+		edits[i] = memberEdit{orig: -1, key: name, value: encodeJSONString(deps[name])}
+	}
+
+	var buf bytes.Buffer
+	d.writeObject(&buf, jsonObject{}, edits, depth)
+	return buf.Bytes()
+}
+
+// encodeJSONString encodes s as a JSON string without escaping HTML characters.
+func encodeJSONString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		panic("never reached: encoding a string cannot fail")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// =============================================================================
+// Object rewriting
+// =============================================================================
+
+// memberEdit describes a member of a rewritten JSON object.
+type memberEdit struct {
+	orig  int    // index of the member in the original object, or -1 for new members
+	key   string // key of the member
+	value []byte // new value, or nil to keep the original value
+}
+
+// writeObject writes obj with the given members to buf.
+// Separators between members that were adjacent in the original object are kept as-is.
+func (d *jsonDocument) writeObject(buf *bytes.Buffer, obj jsonObject, edits []memberEdit, depth int) {
+	if len(edits) == 0 {
+		buf.WriteString("{}")
+		return
+	}
+
+	leading := d.layout.newline + strings.Repeat(d.layout.indent, depth+1)
+	trailing := d.layout.newline + strings.Repeat(d.layout.indent, depth)
+	if len(obj.members) > 0 {
+		leading = string(d.data[obj.start+1 : obj.members[0].keyStart])
+		trailing = string(d.data[obj.members[len(obj.members)-1].valueEnd:obj.end])
+	}
+
+	buf.WriteByte('{')
+	buf.WriteString(leading)
+	for i, edit := range edits {
+		if i > 0 {
+			prev := edits[i-1].orig
+			if prev >= 0 && edit.orig == prev+1 {
+				buf.Write(d.data[obj.members[prev].valueEnd:obj.members[edit.orig].keyStart])
+			} else {
+				buf.WriteByte(',')
+				buf.WriteString(leading)
+			}
+		}
+
+		if edit.orig < 0 {
+			buf.Write(encodeJSONString(edit.key))
+			buf.WriteString(d.layout.colon)
+			buf.Write(edit.value)
+			continue
+		}
+
+		member := obj.members[edit.orig]
+		buf.Write(d.data[member.keyStart:member.valueStart])
+		if edit.value != nil {
+			buf.Write(edit.value)
+		} else {
+			buf.Write(d.value(member))
+		}
+	}
+	buf.WriteString(trailing)
+	buf.WriteByte('}')
+}
+
+// =============================================================================
+// Layout detection
+// =============================================================================
+
+// jsonLayout describes the whitespace conventions of a JSON document.
+type jsonLayout struct {
+	newline string // line separator, empty for single-line documents
+	indent  string // a single level of indentation
+	colon   string // separator between keys and values
+}
+
+// defaultLayout is used for documents that do not have any members to learn from.
+var defaultLayout = jsonLayout{newline: "\n", indent: "    ", colon: ": "}
+
+// detectLayout detects the layout of a document from the members of its root object.
+func detectLayout(data []byte, root jsonObject) jsonLayout {
+	if len(root.members) == 0 {
+		return defaultLayout
+	}
+
+	first := root.members[0]
+	layout := jsonLayout{colon: string(data[first.keyEnd:first.valueStart])}
+
+	leading := string(data[root.start+1 : first.keyStart])
+	idx := strings.LastIndexByte(leading, '\n')
+	if idx < 0 {
+		return layout
+	}
+	layout.newline = "\n"
+	if strings.HasSuffix(leading[:idx], "\r") {
+		layout.newline = "\r\n"
+	}
+	layout.indent = leading[idx+1:]
+	return layout
+}
+
+// =============================================================================
+// Scanner
+// =============================================================================
+
+// jsonDocument scans the byte layout of a JSON document that is known to be valid.
+type jsonDocument struct {
+	data   []byte
+	pos    int
+	layout jsonLayout
+}
+
+// jsonObject describes the byte layout of a JSON object.
+type jsonObject struct {
+	start, end int // offsets of the opening and closing brace
+	members    []jsonMember
+}
+
+// jsonMember describes the byte layout of a single member of a JSON object.
+type jsonMember struct {
+	key              string // decoded key
+	keyStart, keyEnd int    // offsets of the key, including quotes
+	valueStart       int    // offset of the first byte of the value
+	valueEnd         int    // offset just after the last byte of the value
+}
+
+// value returns the original bytes of the value of member.
+func (d *jsonDocument) value(member jsonMember) []byte {
+	return d.data[member.valueStart:member.valueEnd]
+}
+
+// scanRoot scans the root object of the document.
+func (d *jsonDocument) scanRoot() (jsonObject, error) {
+	d.pos = 0
+	d.skipSpace()
+	return d.scanObject()
+}
+
+func (d *jsonDocument) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\r', '\n':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// expect consumes the byte c, or returns an error.
+func (d *jsonDocument) expect(c byte) error {
+	if d.pos >= len(d.data) || d.data[d.pos] != c {
+		return fmt.Errorf("%w: expected %q at offset %d", errMalformedJSON, c, d.pos)
+	}
+	d.pos++
+	return nil
+}
+
+func (d *jsonDocument) scanObject() (obj jsonObject, err error) {
+	obj.start = d.pos
+	if err := d.expect('{'); err != nil {
+		return obj, err
+	}
+	d.skipSpace()
+	if d.pos < len(d.data) && d.data[d.pos] == '}' {
+		obj.end = d.pos
+		d.pos++
+		return obj, nil
+	}
+
+	for {
+		var member jsonMember
+		member.keyStart = d.pos
+		if err := d.skipString(); err != nil {
+			return obj, err
+		}
+		member.keyEnd = d.pos
+		if err := json.Unmarshal(d.data[member.keyStart:member.keyEnd], &member.key); err != nil {
+			return obj, fmt.Errorf("%w: invalid key at offset %d", errMalformedJSON, member.keyStart)
+		}
+
+		d.skipSpace()
+		if err := d.expect(':'); err != nil {
+			return obj, err
+		}
+		d.skipSpace()
+
+		member.valueStart = d.pos
+		if err := d.skipValue(); err != nil {
+			return obj, err
+		}
+		member.valueEnd = d.pos
+		obj.members = append(obj.members, member)
+
+		d.skipSpace()
+		if d.pos < len(d.data) && d.data[d.pos] == '}' {
+			obj.end = d.pos
+			d.pos++
+			return obj, nil
+		}
+		if err := d.expect(','); err != nil {
+			return obj, err
+		}
+		d.skipSpace()
+	}
+}
+
+func (d *jsonDocument) skipValue() error {
+	if d.pos >= len(d.data) {
+		return fmt.Errorf("%w: unexpected end of input", errMalformedJSON)
+	}
+	switch d.data[d.pos] {
+	case '{':
+		_, err := d.scanObject()
+		return err
+	case '[':
+		return d.skipArray()
+	case '"':
+		return d.skipString()
+	}
+
+	// literal: number, true, false or null
+	start := d.pos
+	for d.pos < len(d.data) && !strings.ContainsRune(",}] \t\r\n", rune(d.data[d.pos])) {
+		d.pos++
+	}
+	if d.pos == start {
+		return fmt.Errorf("%w: unexpected %q at offset %d", errMalformedJSON, d.data[d.pos], d.pos)
+	}
+	return nil
+}
+
+func (d *jsonDocument) skipArray() error {
+	if err := d.expect('['); err != nil {
+		return err
+	}
+	d.skipSpace()
+	if d.pos < len(d.data) && d.data[d.pos] == ']' {
+		d.pos++
+		return nil
+	}
+	for {
+		if err := d.skipValue(); err != nil {
+			return err
+		}
+		d.skipSpace()
+		if d.pos < len(d.data) && d.data[d.pos] == ']' {
+			d.pos++
+			return nil
+		}
+		if err := d.expect(','); err != nil {
+			return err
+		}
+		d.skipSpace()
+	}
+}
+
+func (d *jsonDocument) skipString() error {
+	if err := d.expect('"'); err != nil {
+		return err
+	}
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case '\\':
+			d.pos += 2
+		case '"':
+			d.pos++
+			return nil
+		default:
+			d.pos++
+		}
+	}
+	return fmt.Errorf("%w: unterminated string", errMalformedJSON)
+}
@@ -1,9 +1,10 @@
 //spellchecker:words drupalupdate
 package drupalupdate_test
 
-//spellchecker:words encoding json testing github composer drupal update drupalupdate
+//spellchecker:words encoding json strings testing github composer drupal update drupalupdate
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
@@ -129,6 +130,245 @@ func TestMarshalComposerJSON_SortsRequire(t *testing.T) {
 	}
 }
 
+func TestMarshalComposerJSON_RequireDev(t *testing.T) {
+	t.Parallel()
+	input := []byte(`{
+    "require": {"drupal/gin": "^5.0"},
+    "require-dev": {"drupal/core-dev": "^10", "phpunit/phpunit": "^9.6"}
+}`)
+
+	var c drupalupdate.ComposerJSON
+	if err := json.Unmarshal(input, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.RequireDev["phpunit/phpunit"] != "^9.6" {
+		t.Fatalf("expected ^9.6, got %s", c.RequireDev["phpunit/phpunit"])
+	}
+
+	if !c.SetVersion(drupalupdate.SectionRequireDev, "phpunit/phpunit", "^10.5") {
+		t.Fatal("expected SetVersion to update phpunit/phpunit in require-dev")
+	}
+	if c.SetVersion(drupalupdate.SectionRequire, "phpunit/phpunit", "^11") {
+		t.Error("expected SetVersion to ignore phpunit/phpunit in require")
+	}
+
+	output, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c2 drupalupdate.ComposerJSON
+	if err := json.Unmarshal(output, &c2); err != nil {
+		t.Fatal(err)
+	}
+	if c2.RequireDev["phpunit/phpunit"] != "^10.5" {
+		t.Errorf("expected ^10.5, got %s", c2.RequireDev["phpunit/phpunit"])
+	}
+	if _, ok := c2.Require["phpunit/phpunit"]; ok {
+		t.Error("phpunit/phpunit should not be added to require")
+	}
+	if c2.Require["drupal/gin"] != "^5.0" {
+		t.Errorf("expected ^5.0, got %s", c2.Require["drupal/gin"])
+	}
+}
+
+func TestUpdateVersion(t *testing.T) {
+	t.Parallel()
+	input := []byte(`{
+    "require": {"drupal/gin": "^5.0"},
+    "require-dev": {"drush/drush": "^12"}
+}`)
+
+	var c drupalupdate.ComposerJSON
+	if err := json.Unmarshal(input, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.UpdateVersion("drupal/gin", "^6.0") {
+		t.Error("expected drupal/gin to be updated")
+	}
+	if !c.UpdateVersion("drush/drush", "^13") {
+		t.Error("expected drush/drush to be updated")
+	}
+	if c.UpdateVersion("drupal/nonexistent", "^1.0") {
+		t.Error("expected drupal/nonexistent not to be updated")
+	}
+
+	if c.Require["drupal/gin"] != "^6.0" {
+		t.Errorf("expected ^6.0, got %s", c.Require["drupal/gin"])
+	}
+	if c.RequireDev["drush/drush"] != "^13" {
+		t.Errorf("expected ^13, got %s", c.RequireDev["drush/drush"])
+	}
+	if _, ok := c.Require["drupal/nonexistent"]; ok {
+		t.Error("drupal/nonexistent should not be added")
+	}
+}
+
+func TestReplacePackage(t *testing.T) {
+	t.Parallel()
+	input := []byte(`{
+    "require": {"acme/old": "^1.0", "drupal/gin": "^5.0"},
+    "require-dev": {"acme/old": "^1.0", "acme/new": "^2.1"}
+}`)
+
+	var c drupalupdate.ComposerJSON
+	if err := json.Unmarshal(input, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.ReplacePackage("acme/old", "acme/new", "^2.0") {
+		t.Error("expected acme/old to be replaced")
+	}
+	if c.ReplacePackage("drupal/nonexistent", "acme/new", "^2.0") {
+		t.Error("expected drupal/nonexistent not to be replaced")
+	}
+
+	if _, ok := c.Require["acme/old"]; ok {
+		t.Error("acme/old should be removed from require")
+	}
+	if _, ok := c.RequireDev["acme/old"]; ok {
+		t.Error("acme/old should be removed from require-dev")
+	}
+	if c.Require["acme/new"] != "^2.0" {
+		t.Errorf("expected ^2.0 in require, got %s", c.Require["acme/new"])
+	}
+	// already required replacements keep their constraint
+	if c.RequireDev["acme/new"] != "^2.1" {
+		t.Errorf("expected ^2.1 in require-dev, got %s", c.RequireDev["acme/new"])
+	}
+}
+
+// =============================================================================
+// MarshalComposerJSON (formatting preservation)
+// =============================================================================
+
+func TestMarshalComposerJSON_PreservesFormatting(t *testing.T) {
+	t.Parallel()
+	input := "{\n" +
+		"  \"name\": \"drupal/example\",\n" +
+		"  \"description\": \"Sites <with> & without\",\n" +
+		"  \"require\": {\n" +
+		"    \"drupal/gin\": \"^5.0\",\n" +
+		"    \"drupal/admin_toolbar\":   \"^3.6\",\n" +
+		"    \"drush/drush\": \"^12\"\n" +
+		"  },\n" +
+		"  \"extra\": {\"b\": 1, \"a\": [1,2]}\n" +
+		"}\n"
+
+	c, err := drupalupdate.ParseComposerJSON([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged documents are reproduced byte for byte
+	output, err := c.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != input {
+		t.Errorf("unchanged document was modified:\n%s", output)
+	}
+
+	// Changing a constraint only touches its bytes
+	c.Require["drupal/admin_toolbar"] = "^4.0 <5"
+	output, err = c.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(input, "\"^3.6\"", "\"^4.0 <5\"", 1)
+	if string(output) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestMarshalComposerJSON_PreservesTrailingWhitespace(t *testing.T) {
+	t.Parallel()
+	for _, trailer := range []string{"", "\n\n", "\r\n", "  \n\t\n"} {
+		input := "{\"require\": {\"drupal/gin\": \"^5.0\"}}" + trailer
+
+		c, err := drupalupdate.ParseComposerJSON([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := c.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(output) != input {
+			t.Errorf("expected %q, got %q", input, output)
+		}
+
+		c.SetVersion(drupalupdate.SectionRequire, "drupal/gin", "^6.0")
+		output, err = c.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Replace(input, "^5.0", "^6.0", 1); string(output) != want {
+			t.Errorf("expected %q after an update, got %q", want, output)
+		}
+	}
+}
+
+func TestMarshalComposerJSON_AddAndRemove(t *testing.T) {
+	t.Parallel()
+	input := "{\n" +
+		"    \"name\": \"drupal/example\",\n" +
+		"    \"require\": {\n" +
+		"        \"drupal/gin\": \"^5.0\",\n" +
+		"        \"drush/drush\": \"^12\"\n" +
+		"    },\n" +
+		"    \"extra\": {}\n" +
+		"}"
+
+	var c drupalupdate.ComposerJSON
+	if err := json.Unmarshal([]byte(input), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(c.Require, "drupal/gin")
+	c.Require["drupal/admin_toolbar"] = "^3.6"
+	c.RequireDev = map[string]string{"phpunit/phpunit": "^10"}
+	delete(c.Raw, "extra")
+	c.Raw["type"] = json.RawMessage(`"project"`)
+
+	output, err := c.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\n" +
+		"    \"name\": \"drupal/example\",\n" +
+		"    \"require\": {\n" +
+		"        \"drush/drush\": \"^12\",\n" +
+		"        \"drupal/admin_toolbar\": \"^3.6\"\n" +
+		"    },\n" +
+		"    \"type\": \"project\",\n" +
+		"    \"require-dev\": {\n" +
+		"        \"phpunit/phpunit\": \"^10\"\n" +
+		"    }\n" +
+		"}\n"
+	if string(output) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestMarshalComposerJSON_NoSource(t *testing.T) {
+	t.Parallel()
+	c := drupalupdate.ComposerJSON{
+		Require: map[string]string{"drupal/gin": "<6"},
+	}
+
+	output, err := c.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n    \"require\": {\n        \"drupal/gin\": \"<6\"\n    }\n}\n"
+	if string(output) != want {
+		t.Errorf("expected %q, got %q", want, output)
+	}
+}
+
 // =============================================================================
 // DrupalPackages
 // =============================================================================
@@ -210,6 +450,50 @@ func TestComposerPackages(t *testing.T) {
 	}
 }
 
+func TestComposerPackages_RequireDev(t *testing.T) {
+	t.Parallel()
+	input := []byte(`{
+    "require": {
+        "drush/drush": "^13"
+    },
+    "require-dev": {
+        "drupal/core-dev": "^11",
+        "drupal/devel": "^5.0",
+        "phpstan/phpstan": "^1.10",
+        "phpunit/phpunit": "^10"
+    }
+}`)
+	var c drupalupdate.ComposerJSON
+	err := json.Unmarshal(input, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs := c.ComposerPackages()
+	if len(pkgs) != 3 {
+		t.Fatalf("expected 3 composer packages, got %d: %+v", len(pkgs), pkgs)
+	}
+	if pkgs[0].Name != "drush/drush" || pkgs[0].Section != drupalupdate.SectionRequire {
+		t.Errorf("unexpected first package: %+v", pkgs[0])
+	}
+	if pkgs[1].Name != "phpstan/phpstan" || pkgs[1].Section != drupalupdate.SectionRequireDev {
+		t.Errorf("unexpected second package: %+v", pkgs[1])
+	}
+	if pkgs[2].Name != "phpunit/phpunit" || pkgs[2].Section != drupalupdate.SectionRequireDev {
+		t.Errorf("unexpected third package: %+v", pkgs[2])
+	}
+
+	drupalPkgs := c.DrupalPackages()
+	if len(drupalPkgs) != 1 || drupalPkgs[0].Module != "devel" || drupalPkgs[0].Section != drupalupdate.SectionRequireDev {
+		t.Errorf("unexpected drupal packages: %+v", drupalPkgs)
+	}
+
+	corePkgs := c.CorePackages()
+	if len(corePkgs) != 1 || corePkgs[0].Name != "drupal/core-dev" || corePkgs[0].Section != drupalupdate.SectionRequireDev {
+		t.Errorf("unexpected core packages: %+v", corePkgs)
+	}
+}
+
 func TestComposerPackages_Empty(t *testing.T) {
 	t.Parallel()
 	input := []byte(`{
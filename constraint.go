@@ -0,0 +1,340 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words errors regexp strconv strings
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraint is a parsed composer version constraint, e.g. "^9.5 || ^10" or ">=1.2 <2.0".
+// Use [ParseConstraint] to create new instances.
+type Constraint struct {
+	Raw       string // the original constraint string
+	Stability string // lowest stability flag given, e.g. "beta" for "^1.0@beta", or "" if there is none
+
+	groups [][]constraintTerm // alternatives ("||") of terms that must all match
+}
+
+// constraintTerm is a single comparison of a version against a bound.
+type constraintTerm struct {
+	op      string  // one of "any", "==", "!=", ">", ">=", "<", "<=" or "dev"
+	version Version // bound to compare against
+	branch  string  // branch name, only used for "dev" terms
+}
+
+var (
+	errConstraintEmpty   = errors.New("constraint cannot be empty")
+	errConstraintInvalid = errors.New("invalid constraint")
+
+	// constraintOrRegex splits a constraint into alternatives.
+	constraintOrRegex = regexp.MustCompile(`\s*\|\|?\s*`)
+	// constraintAndRegex splits an alternative into terms that must all match.
+	constraintAndRegex = regexp.MustCompile(`[\s,]+`)
+	// constraintOpSpaceRegex matches operators followed by spaces, e.g. ">= 1.2".
+	constraintOpSpaceRegex = regexp.MustCompile(`([<>=!~^]+)\s+`)
+	// constraintAliasRegex matches inline aliases, e.g. "1.0.0 as 1.1.0" or "dev-main as 2.x-dev".
+	constraintAliasRegex = regexp.MustCompile(`^([^,\s]+)\s+as\s+([^,\s]+)$`)
+	// constraintHyphenRegex matches hyphen ranges, e.g. "1.0 - 2.0".
+	constraintHyphenRegex = regexp.MustCompile(`^(\S+)\s+-\s+(\S+)$`)
+	// constraintVersionRegex matches a (partial) version within a constraint, e.g. "1.2", "1.2.*" or "v2.0.0-beta1".
+	constraintVersionRegex = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+|[*xX]))?(?:\.(\d+|[*xX]))?(?:\.(\d+|[*xX]))?(?:[-.]?([a-zA-Z]+)\.?(\d*))?$`)
+)
+
+// ParseConstraint parses a composer version constraint.
+//
+// It supports exact versions ("1.2.3"), comparisons (">=1.2 <2.0", "!=1.5"),
+// caret ("^9.5") and tilde ("~2.1.0") ranges, wildcards ("1.2.*", "*"),
+// hyphen ranges ("1.0 - 2.0"), branches ("1.x-dev", "dev-main"),
+// alternatives ("^9.5 || ^10"), stability flags ("^1.0@beta", "@dev")
+// and inline aliases ("1.0.0 as 1.1.0"), which are matched like the aliased version.
+// Like in composer, branches only match the branch itself, e.g. "1.x-dev" does not match "1.5.0".
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{Raw: s}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return c, errConstraintEmpty
+	}
+	// like composer, an inline alias requires the aliased version
+	if match := constraintAliasRegex.FindStringSubmatch(s); match != nil {
+		s = match[1]
+	}
+
+	for _, alternative := range constraintOrRegex.Split(s, -1) {
+		var group []constraintTerm
+		if match := constraintHyphenRegex.FindStringSubmatch(alternative); match != nil {
+			terms, err := parseHyphenRange(match[1], match[2])
+			if err != nil {
+				return c, err
+			}
+			group = append(group, terms...)
+		} else {
+			alternative = constraintOpSpaceRegex.ReplaceAllString(alternative, "$1")
+			for _, atom := range constraintAndRegex.Split(alternative, -1) {
+				if atom == "" {
+					continue
+				}
+				atom, flag := splitStabilityFlag(atom)
+				if flag != "" && (c.Stability == "" || stabilityRank(flag) < stabilityRank(c.Stability)) {
+					c.Stability = flag
+				}
+				terms, err := parseConstraintAtom(atom)
+				if err != nil {
+					return c, err
+				}
+				group = append(group, terms...)
+			}
+		}
+		if len(group) == 0 {
+			return c, fmt.Errorf("%w: empty alternative in %q", errConstraintInvalid, s)
+		}
+		c.groups = append(c.groups, group)
+	}
+	return c, nil
+}
+
+// String returns the original constraint string.
+func (c Constraint) String() string {
+	return c.Raw
+}
+
+// Matches reports whether the version v satisfies the constraint.
+// Stability flags are not taken into account.
+// Named branches (e.g. "dev-main") only satisfy constraints naming them.
+func (c Constraint) Matches(v Version) bool {
+	if v.Major < 0 && v.Branch == "" {
+		return false
+	}
+	for _, group := range c.groups {
+		if matchesAll(group, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(terms []constraintTerm, v Version) bool {
+	for _, term := range terms {
+		if !term.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t constraintTerm) matches(v Version) bool {
+	if t.op == "dev" || v.Branch != "" {
+		return t.op == "dev" && t.branch == v.Branch
+	}
+
+	cmp := v.compareRelease(t.version)
+	switch t.op {
+	case "any":
+		return true
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+// splitStabilityFlag splits a trailing stability flag (e.g. "@beta") from a constraint atom.
+// An atom consisting only of a flag (e.g. "@dev") is treated as "*".
+func splitStabilityFlag(atom string) (string, string) {
+	idx := strings.LastIndexByte(atom, '@')
+	if idx < 0 {
+		return atom, ""
+	}
+	flag := normalizeStability(atom[idx+1:])
+	atom = atom[:idx]
+	if atom == "" {
+		atom = "*"
+	}
+	return atom, flag
+}
+
+// parseConstraintAtom parses a single constraint without alternatives, e.g. "^1.2" or ">=2.0".
+func parseConstraintAtom(atom string) ([]constraintTerm, error) {
+	if atom == "*" || atom == "x" || atom == "X" {
+		return []constraintTerm{{op: "any"}}, nil
+	}
+	if branch, ok := strings.CutPrefix(atom, "dev-"); ok {
+		return []constraintTerm{{op: "dev", branch: branch}}, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", "<>", "!=", "==", ">", "<", "=", "^", "~"} {
+		if rest, ok := strings.CutPrefix(atom, prefix); ok {
+			op, atom = prefix, rest
+			break
+		}
+	}
+
+	// numbered branches like "1.x-dev" are compared as versions with wildcard segments, see [WildcardSegment]
+	if base, ok := strings.CutSuffix(atom, "-dev"); ok && (strings.HasSuffix(base, ".x") || strings.HasSuffix(base, ".*")) {
+		branch := ParseVersion(atom)
+		if branch.Major < 0 {
+			return nil, fmt.Errorf("%w: branch %q", errConstraintInvalid, atom)
+		}
+		switch op {
+		case "^", "~":
+			return nil, fmt.Errorf("%w: operator %q with branch %q", errConstraintInvalid, op, atom)
+		case "", "=":
+			op = "=="
+		case "<>":
+			op = "!="
+		}
+		return []constraintTerm{{op: op, version: branch}}, nil
+	}
+
+	bound, n, wildcard, err := parseConstraintVersion(atom)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case wildcard:
+		if op != "" && op != "=" && op != "==" {
+			return nil, fmt.Errorf("%w: operator %q with wildcard %q", errConstraintInvalid, op, atom)
+		}
+		if n == 0 {
+			return []constraintTerm{{op: "any"}}, nil
+		}
+		return rangeTerms(bound, bumpSegment(bound, n-1)), nil
+	case op == "^":
+		return rangeTerms(bound, bumpSegment(bound, caretPosition(bound, n))), nil
+	case op == "~":
+		return rangeTerms(bound, bumpSegment(bound, max(n-2, 0))), nil
+	case op == "" || op == "=" || op == "==":
+		return []constraintTerm{{op: "==", version: bound}}, nil
+	case op == "<>":
+		return []constraintTerm{{op: "!=", version: bound}}, nil
+	case op == "<" || op == ">=":
+		// like composer, "<2.0" excludes pre-releases of 2.0 and ">=2.0" includes them
+		if bound.Stability == "" {
+			bound.Stability = StabilityDev
+		}
+		return []constraintTerm{{op: op, version: bound}}, nil
+	default:
+		return []constraintTerm{{op: op, version: bound}}, nil
+	}
+}
+
+// parseHyphenRange parses the bounds of a hyphen range like "1.0 - 2.0".
+// A partial upper bound includes all versions starting with it.
+func parseHyphenRange(from, to string) ([]constraintTerm, error) {
+	lower, _, wildcard, err := parseConstraintVersion(from)
+	if err != nil || wildcard {
+		return nil, fmt.Errorf("%w: hyphen range %q - %q", errConstraintInvalid, from, to)
+	}
+	upper, n, wildcard, err := parseConstraintVersion(to)
+	if err != nil || wildcard {
+		return nil, fmt.Errorf("%w: hyphen range %q - %q", errConstraintInvalid, from, to)
+	}
+	if lower.Stability == "" {
+		lower.Stability = StabilityDev
+	}
+	if n < 3 {
+		return rangeTerms(lower, bumpSegment(upper, n-1)), nil
+	}
+	return []constraintTerm{{op: ">=", version: lower}, {op: "<=", version: upper}}, nil
+}
+
+// parseConstraintVersion parses the version part of a constraint.
+// It returns the version with missing segments set to 0, the number of numeric segments given,
+// and whether the version ends in a wildcard.
+func parseConstraintVersion(s string) (v Version, n int, wildcard bool, err error) {
+	match := constraintVersionRegex.FindStringSubmatch(s)
+	if match == nil {
+		return v, 0, false, fmt.Errorf("%w: version %q", errConstraintInvalid, s)
+	}
+
+	segments := []*int{&v.Major, &v.Minor, &v.Patch, &v.Build}
+	for i, segment := range match[1:5] {
+		if segment == "" {
+			break
+		}
+		if segment == "*" || segment == "x" || segment == "X" {
+			wildcard = true
+			break
+		}
+		n++
+		*segments[i], _ = strconv.Atoi(segment) // cannot fail, segment matched \d+
+	}
+	if match[5] != "" {
+		if wildcard {
+			return v, 0, false, fmt.Errorf("%w: version %q", errConstraintInvalid, s)
+		}
+		v.Stability = normalizeStability(match[5])
+		if match[6] != "" {
+			v.StabilityNumber, _ = strconv.Atoi(match[6]) // cannot fail, matched \d+
+		}
+	}
+	return v, n, wildcard, nil
+}
+
+// caretPosition returns the index of the segment bumped by a caret constraint with n given segments.
+func caretPosition(v Version, n int) int {
+	switch {
+	case v.Major != 0 || n == 1:
+		return 0
+	case v.Minor != 0 || n == 2:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// bumpSegment returns the smallest version whose segment at index pos is one larger than in v.
+// The result is a "dev" version, so that pre-releases of it are excluded by a "<" comparison.
+func bumpSegment(v Version, pos int) Version {
+	next := Version{Stability: StabilityDev}
+	switch pos {
+	case 0:
+		next.Major = v.Major + 1
+	case 1:
+		next.Major, next.Minor = v.Major, v.Minor+1
+	default:
+		next.Major, next.Minor, next.Patch = v.Major, v.Minor, v.Patch+1
+	}
+	return next
+}
+
+// rangeTerms returns the terms of the half-open range [lower, upper).
+func rangeTerms(lower, upper Version) []constraintTerm {
+	if lower.Stability == "" {
+		lower.Stability = StabilityDev
+	}
+	return []constraintTerm{{op: ">=", version: lower}, {op: "<", version: upper}}
+}
+
+// =============================================================================
+// Release Helpers
+// =============================================================================
+
+// MarkAllowed sets Allowed on each release that satisfies the given constraint.
+// Releases that are already allowed do not require a change to composer.json.
+// It does nothing if the constraint cannot be parsed.
+func MarkAllowed(releases []Release, constraint string) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return
+	}
+	for i := range releases {
+		releases[i].Allowed = c.Matches(ParseVersion(releases[i].Version))
+	}
+}
@@ -0,0 +1,151 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words testing github composer drupal update drupalupdate
+import (
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestConstraint_Matches(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^9.5 || ^10", "9.5.0", true},
+		{"^9.5 || ^10", "9.4.9", false},
+		{"^9.5 || ^10", "10.3.1", true},
+		{"^9.5 || ^10", "11.0.0", false},
+		{"^9.5|^10", "10.0.0", true},
+		{"~2.1.0", "2.1.9", true},
+		{"~2.1.0", "2.2.0", false},
+		{"~2.1", "2.9.0", true},
+		{"~2.1", "3.0.0", false},
+		{">=1.2 <2.0", "1.2.0", true},
+		{">=1.2 <2.0", "1.9.9", true},
+		{">=1.2 <2.0", "2.0.0", false},
+		{">=1.2 <2.0", "2.0.0-beta1", false},
+		{">= 1.2, <2.0", "1.5.0", true},
+		{">= 1.2, <2.0", "1.1.0", false},
+		{"1.x-dev", "1.4.0", false},
+		{"1.x-dev", "1.5.0", false},
+		{"1.x-dev", "2.0.0", false},
+		{"1.x-dev", "2.x-dev", false},
+		{"1.0.x-dev", "1.0.x-dev", true},
+		{"1.0.x-dev", "1.x-dev", false},
+		{"1.x-dev || ^2.0", "2.1.0", true},
+		{"1.0.0 as 1.1.0", "1.0.0", true},
+		{"1.0.0 as 1.1.0", "1.1.0", false},
+		{"^1.0 as 1.5.0", "1.2.0", true},
+		{"dev-main as 2.x-dev", "dev-main", true},
+		{"dev-main as 2.x-dev", "2.x-dev", false},
+		{"1.2.*", "1.2.7", true},
+		{"1.2.*", "1.3.0", false},
+		{"*", "42.0.0", true},
+		{"@beta", "1.0.0-beta2", true},
+		{"^1.0@beta", "1.3.0", true},
+		{"^0.3", "0.3.5", true},
+		{"^0.3", "0.4.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"1.0 - 2.0", "2.0.5", true},
+		{"1.0 - 2.0", "2.1.0", false},
+		{"1.0.0 - 2.1.0", "2.1.0", true},
+		{"1.0.0 - 2.1.0", "2.1.1", false},
+		{"!=1.5.0", "1.5.0", false},
+		{"!=1.5.0", "1.5.1", true},
+		{"5.0.3", "5.0.3", true},
+		{"5.0.3", "5.0.4", false},
+		{"dev-main", "1.0.0", false},
+		{"^5.0", "8.x-5.2", true},
+		{"^5.0", "dev-main", false},
+		{"dev-main", "dev-main", true},
+		{"dev-main", "dev-feature", false},
+		{"*", "dev-main", false},
+		{"1.x-dev", "1.x-dev", true},
+		{">=1.0.0-rc2", "1.0.0-rc1", false},
+		{">=1.0.0-rc2", "1.0.0-rc3", true},
+		{"1.2.3.4", "1.2.3.4", true},
+		{"1.2.3.4", "1.2.3.5", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			t.Parallel()
+			c, err := drupalupdate.ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint(%q) returned error: %v", tt.constraint, err)
+			}
+			if got := c.Matches(drupalupdate.ParseVersion(tt.version)); got != tt.want {
+				t.Errorf("ParseConstraint(%q).Matches(%q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraint_Stability(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0", ""},
+		{"^1.0@beta", "beta"},
+		{"^1.0@RC", "RC"},
+		{"@dev", "dev"},
+		{"^1.0@alpha || ^2.0@beta", "alpha"},
+		{"^1.0@stable", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			t.Parallel()
+			c, err := drupalupdate.ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint(%q) returned error: %v", tt.constraint, err)
+			}
+			if c.Stability != tt.want {
+				t.Errorf("ParseConstraint(%q).Stability = %q, want %q", tt.constraint, c.Stability, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, constraint := range []string{"", "   ", "foo", "^1.x", "^1.x-dev", "1.0 as", ">=1.2 ||", "1.0 - foo"} {
+		t.Run(constraint, func(t *testing.T) {
+			t.Parallel()
+			if _, err := drupalupdate.ParseConstraint(constraint); err == nil {
+				t.Errorf("ParseConstraint(%q) expected error, got nil", constraint)
+			}
+		})
+	}
+}
+
+func TestMarkAllowed(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{
+		{Version: "11.1.0"},
+		{Version: "10.4.2"},
+		{Version: "9.5.11"},
+	}
+	drupalupdate.MarkAllowed(releases, "^9.5 || ^10")
+
+	want := []bool{false, true, true}
+	for i, allowed := range want {
+		if releases[i].Allowed != allowed {
+			t.Errorf("release %s: expected allowed = %v, got %v", releases[i].Version, allowed, releases[i].Allowed)
+		}
+	}
+}
+
+func TestMarkAllowed_Invalid(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{{Version: "1.0.0"}}
+	drupalupdate.MarkAllowed(releases, "not a constraint")
+
+	if releases[0].Allowed {
+		t.Error("expected no release to be allowed for an invalid constraint")
+	}
+}
@@ -3,31 +3,99 @@ package drupalupdate
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
+	"time"
 )
 
-// FetchDrupalReleases fetches the latest release per supported branch for a Drupal module.
-func (c *Client) FetchDrupalReleases(ctx context.Context, name string) (result []Release, err error) {
-	return fetchResponse(ctx, c, fmt.Sprintf("%s/%s/current", c.DrupalBaseURL, name), func(body io.Reader) ([]Release, error) {
+// DrupalProject holds information about a project on drupal.org.
+type DrupalProject struct {
+	Name              string        // short name of the project, e.g. "admin_toolbar"
+	Title             string        // human-readable title, e.g. "Admin Toolbar"
+	Status            ProjectStatus // status of the project
+	SupportedBranches []string      // branches supported by the maintainers, e.g. ["3.0.", "4.0."]
+	Releases          []Release     // latest release per supported branch, or all releases in [ModeAll], newest first
+}
+
+// packageReleases returns the releases of p together with its status and supported branches.
+func (p DrupalProject) packageReleases() PackageReleases {
+	return PackageReleases{Releases: p.Releases, Project: p.Name, Status: p.Status, SupportedBranches: p.SupportedBranches}
+}
+
+// FetchDrupalReleases fetches the latest release per supported branch for a Drupal module, or all releases in [ModeAll].
+// Releases less stable than allowed by the stability settings of c are skipped.
+func (c *Client) FetchDrupalReleases(ctx context.Context, name string) ([]Release, error) {
+	project, err := c.FetchDrupalProject(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return project.Releases, nil
+}
+
+// errDrupalProjectNotFound is returned for projects that drupal.org has no release history for.
+var errDrupalProjectNotFound = errors.New("drupal.org project not found")
+
+// FetchDrupalProject fetches information about a drupal.org project,
+// including the latest release per supported branch like [Client.FetchDrupalReleases].
+func (c *Client) FetchDrupalProject(ctx context.Context, name string) (DrupalProject, error) {
+	return fetchResponse(ctx, c, fmt.Sprintf("%s/%s/current", c.DrupalBaseURL, name), func(body io.Reader) (DrupalProject, error) {
 		var history struct {
-			XMLName           xml.Name  `xml:"project"`
-			Title             string    `xml:"title"`
-			SupportedBranches string    `xml:"supported_branches"`
-			Releases          []Release `xml:"releases>release"`
+			XMLName           xml.Name      // "project", or "error" for unknown projects
+			Message           string        `xml:",chardata"` // message of an error
+			Title             string        `xml:"title"`
+			ShortName         string        `xml:"short_name"`
+			ProjectStatus     ProjectStatus `xml:"project_status"`
+			SupportedBranches string        `xml:"supported_branches"`
+			Releases          []struct {
+				Release
+				Date  int64         `xml:"date"`       // unix timestamp
+				Terms []releaseTerm `xml:"terms>term"` // taxonomy terms, e.g. the release type
+			} `xml:"releases>release"`
 		}
 		if err := xml.NewDecoder(body).Decode(&history); err != nil {
-			return nil, fmt.Errorf("decode XML: %w", err)
+			return DrupalProject{}, fmt.Errorf("decode XML: %w", err)
+		}
+		switch history.XMLName.Local {
+		case "project":
+		case "error":
+			return DrupalProject{}, fmt.Errorf("%w: %s", errDrupalProjectNotFound, strings.TrimSpace(history.Message))
+		default:
+			return DrupalProject{}, fmt.Errorf("decode XML: unexpected element <%s>", history.XMLName.Local)
+		}
+
+		releases := make([]Release, len(history.Releases))
+		for i, r := range history.Releases {
+			releases[i] = r.Release
+			releases[i].Security = isSecurityRelease(r.Terms)
+			releases[i].Stability = stabilityName(versionStability(r.Version))
+			releases[i].Branch = ParseVersion(r.Version).ReleaseBranch()
+			if r.Date > 0 {
+				releases[i].Date = time.Unix(r.Date, 0).UTC()
+			}
 		}
 
 		branches := parseSupportedDrupalBranches(history.SupportedBranches)
-		result = latestPerDrupalBranch(history.Releases, branches)
+		var result []Release
+		if filter := c.releaseFilter(); filter.all {
+			result = allDrupalReleases(releases, filter)
+		} else {
+			result = latestPerDrupalBranch(releases, branches, c.Stability)
+		}
 		for i := range result {
 			result[i].VersionPin = ParseVersion(result[i].Version).VersionPin()
 		}
 		sortReleases(result)
-		return result, nil
+
+		return DrupalProject{
+			Name:              history.ShortName,
+			Title:             history.Title,
+			Status:            history.ProjectStatus,
+			SupportedBranches: branches,
+			Releases:          result,
+		}, nil
 	})
 }
 
@@ -48,40 +116,327 @@ func parseSupportedDrupalBranches(branches string) []string {
 	return result
 }
 
-// latestPerDrupalBranch returns the first (latest) published release for each supported branch.
-// If no branches are given, it returns all published releases.
-func latestPerDrupalBranch(releases []Release, supportedBranches []string) []Release {
+// latestPerDrupalBranch returns the latest published release for each supported branch,
+// skipping releases less stable than allowed by settings.
+// The SecurityVersion of each release is set to the newest security release of its branch up to it.
+// If no branches are given, it returns all published releases that are stable enough.
+func latestPerDrupalBranch(releases []Release, supportedBranches []string, settings StabilitySettings) []Release {
 	if len(supportedBranches) == 0 {
 		var result []Release
 		for _, r := range releases {
-			if r.Status != "published" {
+			if r.Status != "published" || !settings.allows(versionStability(r.Version)) {
 				continue
 			}
+			if r.Security {
+				r.SecurityVersion = r.Version
+			}
 			result = append(result, r)
 		}
 		return result
 	}
 
-	found := make(map[string]Release)
+	candidates := make(map[string][]Release)
 	for _, r := range releases {
 		if r.Status != "published" {
 			continue
 		}
 		for _, branch := range supportedBranches {
-			if !strings.HasPrefix(r.Version, branch) {
-				continue
-			}
-			if _, exists := found[branch]; !exists {
-				found[branch] = r
+			if strings.HasPrefix(r.Version, branch) {
+				candidates[branch] = append(candidates[branch], r)
 			}
 		}
 	}
 
 	var result []Release
 	for _, branch := range supportedBranches {
-		if r, ok := found[branch]; ok {
-			result = append(result, r)
+		stabilities := make([]string, len(candidates[branch]))
+		for i, r := range candidates[branch] {
+			stabilities[i] = versionStability(r.Version)
+		}
+		if i := settings.pickRelease(stabilities); i >= 0 {
+			result = append(result, withSecurityVersion(candidates[branch][i], candidates[branch][i:]))
+		}
+	}
+	return result
+}
+
+// allDrupalReleases returns all published releases allowed by filter.
+// The SecurityVersion of each release is set to the newest security release of its branch up to it.
+// Releases are assumed to be ordered newest first.
+func allDrupalReleases(releases []Release, filter releaseFilter) []Release {
+	var result []Release
+	for i, r := range releases {
+		if r.Status != "published" || !filter.allows(versionStability(r.Version)) {
+			continue
 		}
+		var older []Release
+		for _, o := range releases[i:] {
+			if o.Status == "published" && o.Branch == r.Branch {
+				older = append(older, o)
+			}
+		}
+		result = append(result, withSecurityVersion(r, older))
 	}
 	return result
 }
+
+// MarkCoreCompatibility sets CoreIncompatible on each release whose core compatibility
+// does not allow the targeted drupal core version (e.g. "11.1.0").
+// Releases without a (parsable) core compatibility are assumed to be compatible.
+// It does nothing if core is not a numbered version.
+func MarkCoreCompatibility(releases []Release, core string) {
+	target := ParseVersion(strings.TrimPrefix(core, "v"))
+	if target.Major < 0 {
+		return
+	}
+	for i := range releases {
+		c, err := ParseConstraint(releases[i].CoreCompatibility)
+		if err != nil {
+			continue
+		}
+		releases[i].CoreIncompatible = !c.Matches(target)
+	}
+}
+
+// =============================================================================
+// Composer Metadata
+// =============================================================================
+
+// DrupalSource selects where releases of drupal/* modules are fetched from.
+// Releases of drupal core are always fetched from the release history.
+type DrupalSource string
+
+const (
+	DrupalSourceXML      DrupalSource = "xml"      // the drupal.org release history, the default
+	DrupalSourceComposer DrupalSource = "composer" // the drupal.org composer repository, falling back to the release history
+)
+
+var errDrupalSourceInvalid = errors.New("invalid drupal source")
+
+// ParseDrupalSource parses the name of a drupal source.
+// An empty name results in [DrupalSourceXML].
+func ParseDrupalSource(s string) (DrupalSource, error) {
+	if s == "" {
+		return DrupalSourceXML, nil
+	}
+	switch source := DrupalSource(strings.ToLower(s)); source {
+	case DrupalSourceXML, DrupalSourceComposer:
+		return source, nil
+	default:
+		return "", fmt.Errorf("%w: %q", errDrupalSourceInvalid, s)
+	}
+}
+
+// FetchDrupalComposerReleases fetches the latest release per major version of a Drupal module,
+// or all releases in [ModeAll], from the drupal.org composer repository.
+// Unlike the release history, its metadata includes the requirements of each release,
+// so that releases that cannot be installed on the platform PHP version of c are skipped.
+// The project status, supported branches and security releases are not known.
+func (c *Client) FetchDrupalComposerReleases(ctx context.Context, name string) ([]Release, error) {
+	// the repository is configured on c, so it is not restricted by the RepositoryPolicy of custom repositories
+	client := *c
+	client.RepositoryPolicy = nil
+
+	repo := Repository{Type: RepositoryComposer, URL: c.DrupalComposerURL}
+	found, err := client.fetchComposerRepositoryReleases(ctx, repo, "drupal/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("drupal composer repository: %w", err)
+	}
+	return found.Releases, nil
+}
+
+// =============================================================================
+// Project Resolution
+// =============================================================================
+
+// drupalProjectRepositoryRegex matches the repository of a drupal.org project, e.g. "https://git.drupalcode.org/project/admin_toolbar.git".
+var drupalProjectRepositoryRegex = regexp.MustCompile(`^https?://git\.drupalcode\.org/project/([a-z0-9_]+?)(?:\.git)?/?$`)
+
+// ResolveDrupalProject returns the name of the drupal.org project providing a drupal/* package,
+// e.g. "admin_toolbar" for the sub-module "drupal/admin_toolbar_tools".
+//
+// Packages in the Projects of c resolve to the mapped project, and drupal core packages to "drupal".
+// Other packages are looked up in the drupal.org composer repository, if any.
+// If the lookup fails, the module name is returned together with the error.
+// It returns errNotInRepository for other packages.
+func (c *Client) ResolveDrupalProject(ctx context.Context, pkg string) (string, error) {
+	name, ok := drupalModuleName(pkg)
+	if !ok {
+		return "", errNotInRepository
+	}
+	if project, ok := c.Projects[pkg]; ok {
+		return project, nil
+	}
+	if isCorePackage(name) {
+		return "drupal", nil
+	}
+	if c.DrupalComposerURL == "" {
+		return name, nil
+	}
+	project, err := c.lookupDrupalProject(ctx, pkg)
+	if err != nil {
+		return name, err
+	}
+	return project, nil
+}
+
+// lookupDrupalProject looks up the drupal.org project of pkg in the drupal.org composer repository, see [drupalProjectOf].
+// Only the latest releases are fetched, without development branches, which are in a separate metadata file.
+func (c *Client) lookupDrupalProject(ctx context.Context, pkg string) (string, error) {
+	client := *c
+	client.Mode, client.Include = ModeLatest, nil
+	client.Stability = StabilitySettings{MinimumStability: StabilityAlpha}
+	client.PlatformPHP = ""
+
+	releases, err := client.FetchDrupalComposerReleases(ctx, strings.TrimPrefix(pkg, "drupal/"))
+	if err != nil {
+		return "", err
+	}
+	if project, ok := drupalProjectOf(releases); ok {
+		return project, nil
+	}
+	return "", fmt.Errorf("%w: %s", errDrupalProjectNotFound, pkg)
+}
+
+// drupalProjectOf returns the drupal.org project of a package from its releases in the drupal.org composer repository.
+// The project is taken from the source repository of the newest release,
+// or from the package it requires with the same version, as done by sub-modules.
+func drupalProjectOf(releases []Release) (string, bool) {
+	for _, release := range releases {
+		if match := drupalProjectRepositoryRegex.FindStringSubmatch(release.SourceURL); match != nil {
+			return match[1], true
+		}
+		for required, constraint := range release.Require {
+			name, ok := drupalModuleName(required)
+			if ok && !isCorePackage(name) && (constraint == "self.version" || constraint == release.Version) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// =============================================================================
+// Security Releases
+// =============================================================================
+
+// releaseTerm is a taxonomy term of a drupal.org release, e.g. "Release type" with value "Security update".
+type releaseTerm struct {
+	Name  string `xml:"name"`
+	Value string `xml:"value"`
+}
+
+// isSecurityRelease reports whether the terms of a drupal.org release mark it as a security update.
+func isSecurityRelease(terms []releaseTerm) bool {
+	for _, term := range terms {
+		if strings.EqualFold(term.Name, "Release type") && strings.EqualFold(term.Value, "Security update") {
+			return true
+		}
+	}
+	return false
+}
+
+// withSecurityVersion sets the SecurityVersion of release to the newest security release among older,
+// the releases of its branch ordered newest first, starting with release itself.
+func withSecurityVersion(release Release, older []Release) Release {
+	for _, r := range older {
+		if r.Security {
+			release.SecurityVersion = r.Version
+			break
+		}
+	}
+	return release
+}
+
+// SecurityUpdate returns the newest security release that the current constraint of a package is below,
+// i.e. whose version is newer than the lower bound of the constraint within the same major version.
+// Such a constraint allows installing releases with known security issues.
+// It returns "" if there is no such release, or the constraint cannot be parsed.
+func SecurityUpdate(releases []Release, current string) string {
+	c, err := ParseConstraint(current)
+	if err != nil {
+		return ""
+	}
+	base := c.base()
+	if base.Major < 0 {
+		return ""
+	}
+
+	var newest Version
+	security := ""
+	for _, release := range releases {
+		if release.SecurityVersion == "" {
+			continue
+		}
+		v := ParseVersion(release.SecurityVersion)
+		if v.Major != base.Major || v.compareSegments(base) <= 0 {
+			continue
+		}
+		if security == "" || v.compareRelease(newest) > 0 {
+			newest, security = v, release.SecurityVersion
+		}
+	}
+	return security
+}
+
+// =============================================================================
+// Project Status
+// =============================================================================
+
+// ProjectStatus is the status of a drupal.org project, from the "project_status" of its release history.
+type ProjectStatus string
+
+const (
+	ProjectPublished   ProjectStatus = "published"   // the project is maintained
+	ProjectUnsupported ProjectStatus = "unsupported" // the project is no longer supported by its maintainers
+	ProjectInsecure    ProjectStatus = "insecure"    // the project has unfixed security issues
+	ProjectObsolete    ProjectStatus = "obsolete"    // the project has been replaced, e.g. by another project or drupal core
+)
+
+// Warning returns a warning about the project for display, or "" if the status is unknown or the project is published.
+func (s ProjectStatus) Warning() string {
+	switch s {
+	case "", ProjectPublished:
+		return ""
+	case ProjectUnsupported:
+		return "project is no longer supported by its maintainers"
+	case ProjectInsecure:
+		return "project is marked insecure and has unfixed security issues"
+	case ProjectObsolete:
+		return "project is obsolete and should be replaced"
+	default:
+		return "project status is " + string(s)
+	}
+}
+
+// BranchUnsupported reports whether none of the supported branches of a drupal.org project
+// contains the lower bound of the current constraint, e.g. "^3.0" when only "4.0." and "5.0." are supported.
+// It returns false if no branches are known or the constraint has no lower bound.
+func BranchUnsupported(current string, branches []string) bool {
+	if len(branches) == 0 {
+		return false
+	}
+	c, err := ParseConstraint(current)
+	if err != nil {
+		return false
+	}
+	base := c.base()
+	if base.Major < 0 {
+		return false
+	}
+	for _, branch := range branches {
+		if branchContains(branch, base) {
+			return false
+		}
+	}
+	return true
+}
+
+// branchContains reports whether a supported branch like "4.0." or "8.x-2." contains the version v.
+func branchContains(branch string, v Version) bool {
+	b := ParseVersion(strings.TrimSuffix(branch, "."))
+	if b.Major < 0 || b.Major != v.Major {
+		return false
+	}
+	return b.Minor < 0 || b.Minor == seg(v.Minor)
+}
@@ -46,7 +46,7 @@ func TestLatestPerDrupalBranch_WithBranches(t *testing.T) {
 	}
 	branches := []string{"3.0.", "4.0.", "5.0."}
 
-	got := latestPerDrupalBranch(releases, branches)
+	got := latestPerDrupalBranch(releases, branches, StabilitySettings{})
 
 	// Should return one per branch, in branch order
 	if len(got) != 3 {
@@ -71,7 +71,7 @@ func TestLatestPerDrupalBranch_SkipsUnpublished(t *testing.T) {
 	}
 	branches := []string{"2.0."}
 
-	got := latestPerDrupalBranch(releases, branches)
+	got := latestPerDrupalBranch(releases, branches, StabilitySettings{})
 
 	if len(got) != 1 {
 		t.Fatalf("expected 1 release, got %d", len(got))
@@ -89,7 +89,7 @@ func TestLatestPerDrupalBranch_NoBranches_FallsBackToRecent(t *testing.T) {
 		{Version: "1.0.0", Status: "published"},
 	}
 
-	got := latestPerDrupalBranch(releases, nil)
+	got := latestPerDrupalBranch(releases, nil, StabilitySettings{})
 
 	if len(got) != 3 {
 		t.Fatalf("expected 3 releases, got %d", len(got))
@@ -103,7 +103,7 @@ func TestLatestPerDrupalBranch_MissingBranch(t *testing.T) {
 	}
 	branches := []string{"4.0.", "5.0."}
 
-	got := latestPerDrupalBranch(releases, branches)
+	got := latestPerDrupalBranch(releases, branches, StabilitySettings{})
 
 	// Only branch 5.0. has a release
 	if len(got) != 1 {
@@ -113,3 +113,71 @@ func TestLatestPerDrupalBranch_MissingBranch(t *testing.T) {
 		t.Errorf("expected 5.0.1, got %s", got[0].Version)
 	}
 }
+
+func TestLatestPerDrupalBranch_Stability(t *testing.T) {
+	t.Parallel()
+	releases := []Release{
+		{Version: "8.x-2.x-dev", Status: "published"},
+		{Version: "8.x-2.0-rc2", Status: "published"},
+		{Version: "8.x-2.0-beta1", Status: "published"},
+		{Version: "8.x-1.x-dev", Status: "published"},
+		{Version: "8.x-1.5-beta1", Status: "published"},
+		{Version: "8.x-1.4", Status: "published"},
+	}
+	branches := []string{"8.x-1.", "8.x-2."}
+
+	tests := []struct {
+		name     string
+		settings StabilitySettings
+		want     []string
+	}{
+		{"stable", StabilitySettings{}, []string{"8.x-1.4"}},
+		{"RC", StabilitySettings{MinimumStability: StabilityRC}, []string{"8.x-1.4", "8.x-2.0-rc2"}},
+		{"beta", StabilitySettings{MinimumStability: StabilityBeta}, []string{"8.x-1.5-beta1", "8.x-2.0-rc2"}},
+		{"beta, prefer stable", StabilitySettings{MinimumStability: StabilityBeta, PreferStable: true}, []string{"8.x-1.4", "8.x-2.0-rc2"}},
+		{"dev", StabilitySettings{MinimumStability: StabilityDev}, []string{"8.x-1.x-dev", "8.x-2.x-dev"}},
+	}
+	for _, tt := range tests {
+		got := latestPerDrupalBranch(releases, branches, tt.settings)
+		versions := make([]string, len(got))
+		for i, r := range got {
+			versions[i] = r.Version
+		}
+		if !reflect.DeepEqual(versions, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, versions, tt.want)
+		}
+	}
+}
+
+func TestLatestPerDrupalBranch_SecurityVersion(t *testing.T) {
+	t.Parallel()
+	releases := []Release{
+		{Version: "5.0.3", Status: "published"},
+		{Version: "5.0.2", Status: "published", Security: true},
+		{Version: "5.0.1", Status: "published", Security: true},
+		{Version: "4.0.1", Status: "published"},
+	}
+	branches := []string{"4.0.", "5.0."}
+
+	got := latestPerDrupalBranch(releases, branches, StabilitySettings{})
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 releases, got %d", len(got))
+	}
+	if got[0].SecurityVersion != "" {
+		t.Errorf("branch 4.0.: expected no security version, got %q", got[0].SecurityVersion)
+	}
+	if got[1].SecurityVersion != "5.0.2" {
+		t.Errorf("branch 5.0.: expected security version 5.0.2, got %q", got[1].SecurityVersion)
+	}
+}
+
+func TestIsSecurityRelease(t *testing.T) {
+	t.Parallel()
+	if !isSecurityRelease([]releaseTerm{{Name: "Release type", Value: "New features"}, {Name: "Release type", Value: "Security update"}}) {
+		t.Error("expected security release")
+	}
+	if isSecurityRelease([]releaseTerm{{Name: "Release type", Value: "Bug fixes"}}) {
+		t.Error("expected no security release")
+	}
+}
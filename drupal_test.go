@@ -3,6 +3,8 @@ package drupalupdate_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
@@ -17,30 +19,42 @@ const sampleXML = `<?xml version="1.0" encoding="utf-8"?>
 <project xmlns:dc="http://purl.org/dc/elements/1.1/">
   <title>Admin Toolbar</title>
   <short_name>admin_toolbar</short_name>
+  <project_status>published</project_status>
   <supported_branches>3.0.,4.0.</supported_branches>
   <releases>
     <release>
       <name>admin_toolbar 4.0.2</name>
       <version>4.0.2</version>
       <status>published</status>
+      <date>1731000000</date>
       <core_compatibility>^10.3 || ^11</core_compatibility>
+      <terms>
+        <term><name>Release type</name><value>Bug fixes</value></term>
+      </terms>
     </release>
     <release>
       <name>admin_toolbar 4.0.1</name>
       <version>4.0.1</version>
       <status>published</status>
+      <date>1700000000</date>
       <core_compatibility>^10.3 || ^11</core_compatibility>
+      <terms>
+        <term><name>Release type</name><value>Security update</value></term>
+        <term><name>Release type</name><value>Bug fixes</value></term>
+      </terms>
     </release>
     <release>
       <name>admin_toolbar 3.0.5</name>
       <version>3.0.5</version>
       <status>published</status>
+      <date>1600000000</date>
       <core_compatibility>^9 || ^10</core_compatibility>
     </release>
     <release>
       <name>admin_toolbar 3.0.4</name>
       <version>3.0.4</version>
       <status>published</status>
+      <date>1590000000</date>
       <core_compatibility>^9 || ^10</core_compatibility>
     </release>
   </releases>
@@ -88,6 +102,124 @@ func TestFetchDrupalReleases(t *testing.T) {
 	if releases[1].VersionPin != "^3.0" {
 		t.Errorf("expected version pin '^3.0', got %s", releases[1].VersionPin)
 	}
+	if releases[0].Security || releases[0].SecurityVersion != "4.0.1" {
+		t.Errorf("expected 4.0.2 to not be a security update with security version 4.0.1, got %v and %q", releases[0].Security, releases[0].SecurityVersion)
+	}
+	if releases[1].SecurityVersion != "" {
+		t.Errorf("expected no security version for 3.0.5, got %q", releases[1].SecurityVersion)
+	}
+}
+
+// sampleUnsupportedXML is a drupal.org release history response of an unsupported project.
+const sampleUnsupportedXML = `<?xml version="1.0" encoding="utf-8"?>
+<project xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <title>Old Module</title>
+  <short_name>old_module</short_name>
+  <project_status>unsupported</project_status>
+  <releases>
+    <release>
+      <name>old_module 8.x-1.2</name>
+      <version>8.x-1.2</version>
+      <status>published</status>
+    </release>
+  </releases>
+</project>`
+
+func TestFetchDrupalProject(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/admin_toolbar/current":
+			body = sampleXML
+		case "/old_module/current":
+			body = sampleUnsupportedXML
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = server.URL
+
+	project, err := client.FetchDrupalProject(t.Context(), "admin_toolbar")
+	if err != nil {
+		t.Fatalf("FetchDrupalProject returned error: %v", err)
+	}
+	if project.Name != "admin_toolbar" || project.Title != "Admin Toolbar" {
+		t.Errorf("expected admin_toolbar (Admin Toolbar), got %s (%s)", project.Name, project.Title)
+	}
+	if project.Status != drupalupdate.ProjectPublished {
+		t.Errorf("expected status published, got %q", project.Status)
+	}
+	if len(project.SupportedBranches) != 2 || len(project.Releases) != 2 {
+		t.Errorf("expected 2 supported branches and releases, got %v and %d", project.SupportedBranches, len(project.Releases))
+	}
+
+	found, err := client.FetchPackageReleases(t.Context(), "drupal/old_module")
+	if err != nil {
+		t.Fatalf("FetchPackageReleases returned error: %v", err)
+	}
+	if found.Status != drupalupdate.ProjectUnsupported {
+		t.Errorf("expected status unsupported, got %q", found.Status)
+	}
+	if len(found.Releases) != 1 {
+		t.Errorf("expected 1 release, got %d", len(found.Releases))
+	}
+}
+
+func TestProjectStatus_Warning(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status drupalupdate.ProjectStatus
+		want   bool
+	}{
+		{"", false},
+		{drupalupdate.ProjectPublished, false},
+		{drupalupdate.ProjectUnsupported, true},
+		{drupalupdate.ProjectInsecure, true},
+		{drupalupdate.ProjectObsolete, true},
+		{"unpublished", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			t.Parallel()
+			if got := tt.status.Warning(); (got != "") != tt.want {
+				t.Errorf("ProjectStatus(%q).Warning() = %q, want warning %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchUnsupported(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		current  string
+		branches []string
+		want     bool
+	}{
+		{"^4.0", []string{"3.0.", "4.0."}, false},
+		{"^2.0", []string{"3.0.", "4.0."}, true},
+		{"^10.3", []string{"10.4.", "11.1."}, true},
+		{"^10.4", []string{"10.4.", "11.1."}, false},
+		{"^2.1", []string{"8.x-2."}, false},
+		{"^1.0", []string{"8.x-2."}, true},
+		{"^2.0", nil, false},
+		{"*", []string{"3.0."}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.BranchUnsupported(tt.current, tt.branches); got != tt.want {
+				t.Errorf("BranchUnsupported(%q, %v) = %v, want %v", tt.current, tt.branches, got, tt.want)
+			}
+		})
+	}
 }
 
 func TestFetchDrupalReleases_NotFound(t *testing.T) {
@@ -125,3 +257,298 @@ func TestFetchDrupalReleases_InvalidXML(t *testing.T) {
 		t.Fatal("expected error for invalid XML")
 	}
 }
+
+func TestSecurityUpdate(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{
+		{Version: "4.0.2", SecurityVersion: "4.0.1"},
+		{Version: "3.0.5", SecurityVersion: "3.0.5", Security: true},
+		{Version: "2.1.0"},
+	}
+	tests := []struct {
+		current string
+		want    string
+	}{
+		{"^4.0", "4.0.1"},
+		{"^4.0.1", ""},
+		{"^4.0.2", ""},
+		{"^3.0 || ^4.0.1", ""},
+		{"~3.0.4", "3.0.5"},
+		{"^2.0", ""},
+		{"*", ""},
+		{"not a constraint", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.SecurityUpdate(releases, tt.current); got != tt.want {
+				t.Errorf("SecurityUpdate(%q) = %q, want %q", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkCoreCompatibility(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{
+		{Version: "4.0.2", CoreCompatibility: "^10.3 || ^11"},
+		{Version: "3.0.5", CoreCompatibility: "^9 || ^10"},
+		{Version: "2.0.0", CoreCompatibility: "8.x"},
+		{Version: "1.0.0"},
+	}
+
+	drupalupdate.MarkCoreCompatibility(releases, "11.1.0")
+
+	want := []bool{false, true, true, false}
+	for i, incompatible := range want {
+		if releases[i].CoreIncompatible != incompatible {
+			t.Errorf("release %s: expected core incompatible = %v, got %v", releases[i].Version, incompatible, releases[i].CoreIncompatible)
+		}
+	}
+}
+
+func TestMarkCoreCompatibility_NoTarget(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{{Version: "3.0.5", CoreCompatibility: "^9 || ^10"}}
+
+	drupalupdate.MarkCoreCompatibility(releases, "")
+
+	if releases[0].CoreIncompatible {
+		t.Error("expected no marks without a target core version")
+	}
+}
+
+// =============================================================================
+// Drupal Composer Metadata
+// =============================================================================
+
+// sampleDrupalComposerJSON is a packages.drupal.org p2 response for testing.
+const sampleDrupalComposerJSON = `{"packages": {"drupal/admin_toolbar": [
+	{"version": "3.6.0", "version_normalized": "3.6.0.0", "require": {"drupal/core": "^10.3 || ^11", "php": ">=8.3"}, "extra": {"drupal": {"version": "3.6.0", "datestamp": "1740000000"}}},
+	{"version": "3.5.0", "version_normalized": "3.5.0.0", "require": {"drupal/core": "^9.5 || ^10 || ^11", "php": ">=8.1"}, "extra": {"drupal": {"version": "3.5.0", "datestamp": "1731000000"}}},
+	{"version": "2.5.0", "version_normalized": "2.5.0.0", "require": {"drupal/core": "^8.8 || ^9"}, "extra": {"drupal": {"version": "8.x-2.5", "datestamp": "1600000000"}}}
+]}}`
+
+// newDrupalComposerServer creates a mock drupal.org server that serves the release history,
+// and the composer repository at "/8" if composer is set.
+func newDrupalComposerServer(t *testing.T, composer bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch {
+		case r.URL.Path == "/admin_toolbar/current":
+			body = sampleXML
+		case composer && r.URL.Path == "/8/packages.json":
+			body = `{"metadata-url": "/8/files/p2/%package%.json"}`
+		case composer && r.URL.Path == "/8/files/p2/drupal/admin_toolbar.json":
+			body = sampleDrupalComposerJSON
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchReleases_DrupalComposer(t *testing.T) {
+	t.Parallel()
+	server := newDrupalComposerServer(t, true)
+
+	client := drupalupdate.NewClient().WithDrupalSource(drupalupdate.DrupalSourceComposer).WithPlatformPHP("8.1")
+	client.DrupalBaseURL = server.URL
+	client.DrupalComposerURL = server.URL + "/8"
+	client.PackagistBaseURL = ""
+
+	releases, err := client.FetchReleases(t.Context(), "drupal/admin_toolbar")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+
+	// 3.6.0 requires PHP 8.3, so 3.5.0 is the latest release for PHP 8.1
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 releases, got %+v", releases)
+	}
+	if releases[0].Version != "3.5.0" || releases[0].CoreCompatibility != "^9.5 || ^10 || ^11" || releases[0].PHP != ">=8.1" {
+		t.Errorf("unexpected first release: %+v", releases[0])
+	}
+	if releases[0].Require["drupal/core"] != "^9.5 || ^10 || ^11" {
+		t.Errorf("expected require of drupal/core, got %v", releases[0].Require)
+	}
+	if releases[1].Version != "8.x-2.5" || releases[1].VersionPin != "^2.5" {
+		t.Errorf("expected the drupal.org version 8.x-2.5 pinned to ^2.5, got %q and %q", releases[1].Version, releases[1].VersionPin)
+	}
+	if got := releases[1].Date.Unix(); got != 1600000000 {
+		t.Errorf("expected date from the datestamp, got %d", got)
+	}
+}
+
+func TestFetchReleases_DrupalComposerFallback(t *testing.T) {
+	t.Parallel()
+	server := newDrupalComposerServer(t, false)
+
+	client := drupalupdate.NewClient().WithDrupalSource(drupalupdate.DrupalSourceComposer)
+	client.DrupalBaseURL = server.URL
+	client.DrupalComposerURL = server.URL + "/8"
+	client.PackagistBaseURL = ""
+
+	releases, err := client.FetchReleases(t.Context(), "drupal/admin_toolbar")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "4.0.2" {
+		t.Errorf("expected releases from the release history, got %+v", releases)
+	}
+}
+
+func TestParseDrupalSource(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    drupalupdate.DrupalSource
+		wantErr bool
+	}{
+		{"", drupalupdate.DrupalSourceXML, false},
+		{"xml", drupalupdate.DrupalSourceXML, false},
+		{"Composer", drupalupdate.DrupalSourceComposer, false},
+		{"packagist", "", true},
+	}
+	for _, tt := range tests {
+		got, err := drupalupdate.ParseDrupalSource(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDrupalSource(%q) = %q, %v, want %q (error: %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// =============================================================================
+// Project Resolution
+// =============================================================================
+
+// sampleNotFoundXML is the drupal.org release history response for unknown projects.
+const sampleNotFoundXML = `<?xml version="1.0" encoding="utf-8"?>
+<error>No release history was found for the requested project (admin_toolbar_tools).</error>`
+
+// newProjectServer creates a mock drupal.org server with the sub-modules admin_toolbar_tools,
+// provided by admin_toolbar, and admin_toolbar_search, requiring it at the same version.
+func newProjectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/admin_toolbar/current":
+			body = sampleXML
+		case "/admin_toolbar_tools/current", "/admin_toolbar_search/current":
+			body = sampleNotFoundXML
+		case "/8/packages.json":
+			body = `{"metadata-url": "/8/p2/%package%.json"}`
+		case "/8/p2/drupal/admin_toolbar_tools.json":
+			body = `{"packages": {"drupal/admin_toolbar_tools": [
+				{"version": "3.5.0", "version_normalized": "3.5.0.0", "source": {"url": "https://git.drupalcode.org/project/admin_toolbar.git"}}
+			]}}`
+		case "/8/p2/drupal/admin_toolbar_search.json":
+			body = `{"packages": {"drupal/admin_toolbar_search": [
+				{"version": "3.5.0", "version_normalized": "3.5.0.0", "type": "metapackage", "require": {"drupal/core": "^10 || ^11", "drupal/admin_toolbar": "self.version"}}
+			]}}`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolveDrupalProject(t *testing.T) {
+	t.Parallel()
+	server := newProjectServer(t)
+
+	client := drupalupdate.NewClient().WithProjects(map[string]string{"drupal/gin_toolbar": "gin"})
+	client.DrupalBaseURL = server.URL
+	client.DrupalComposerURL = server.URL + "/8"
+	client.PackagistBaseURL = ""
+
+	tests := []struct {
+		pkg     string
+		want    string
+		wantErr bool
+	}{
+		{"drupal/gin_toolbar", "gin", false},
+		{"drupal/core-recommended", "drupal", false},
+		{"drupal/admin_toolbar_tools", "admin_toolbar", false},
+		{"drupal/admin_toolbar_search", "admin_toolbar", false},
+		{"drupal/unknown", "unknown", true},
+		{"drush/drush", "", true},
+	}
+	for _, tt := range tests {
+		got, err := client.ResolveDrupalProject(t.Context(), tt.pkg)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveDrupalProject(%q) = %q, %v, want %q (error: %v)", tt.pkg, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFetchAll_SubModules(t *testing.T) {
+	t.Parallel()
+	project := newProjectServer(t)
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		project.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = server.URL
+	client.DrupalComposerURL = server.URL + "/8"
+	client.PackagistBaseURL = ""
+	client.PackagistAPIURL = ""
+
+	results := client.FetchAll(t.Context(), []drupalupdate.Package{
+		{Name: "drupal/admin_toolbar_tools", Version: "^3.0"},
+		{Name: "drupal/admin_toolbar_search", Version: "^3.0"},
+	})
+	for _, result := range results {
+		if result.Err != nil || result.Releases.Project != "admin_toolbar" || len(result.Releases.Releases) == 0 {
+			t.Errorf("%s: expected the releases of admin_toolbar, got %+v (error: %v)", result.Package.Name, result.Releases, result.Err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := requests["/8/packages.json"]; got != 1 {
+		t.Errorf("expected packages.json to be fetched once, got %d requests", got)
+	}
+	for path := range requests {
+		if strings.Contains(path, "~dev") {
+			t.Errorf("expected no development branches to be fetched, got a request for %s", path)
+		}
+	}
+}
+
+func TestFetchReleases_SubModule(t *testing.T) {
+	t.Parallel()
+	server := newProjectServer(t)
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = server.URL
+	client.DrupalComposerURL = server.URL + "/8"
+	client.PackagistBaseURL = ""
+
+	releases, err := client.FetchReleases(t.Context(), "drupal/admin_toolbar_tools")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "4.0.2" {
+		t.Errorf("expected the releases of admin_toolbar, got %+v", releases)
+	}
+}
@@ -13,6 +13,18 @@
  * @property {string} name   - e.g. "drupal/gin" or "drush/drush"
  * @property {string} module - identifier for fetching releases
  * @property {string} version
+ * @property {"require" | "require-dev"} [section] - composer.json section the package was found in
+ * @property {string} [installed] - exact version from composer.lock
+ * @property {string} [reference] - source reference from composer.lock
+ * @property {boolean} [abandoned] - true if the package is abandoned according to composer.lock
+ * @property {string} [replacement] - package suggested to replace the abandoned package
+ * @property {Patch[]} [patches] - patches applied by composer-patches
+ */
+
+/**
+ * @typedef {Object} Patch
+ * @property {string} description
+ * @property {string} url
  */
 
 /**
@@ -21,6 +33,29 @@
  * @property {string} version
  * @property {string} version_pin
  * @property {string} [core_compatibility]
+ * @property {string} [php] - PHP requirement of the release, if known
+ * @property {"installed" | "newer" | "older"} [relation] - relation to the installed version
+ * @property {boolean} [allowed] - true if the release already satisfies the current constraint
+ * @property {boolean} [core_incompatible] - true if the release does not support the targeted Drupal core version
+ * @property {"none" | "patch" | "minor" | "major" | "pre-release"} [update] - size of the update from the current constraint
+ * @property {boolean} [security] - true if the release is a security update
+ * @property {string} [security_version] - newest security release of the branch up to this release
+ * @property {string} [stability] - stability of the release, e.g. "stable", "RC" or "dev"
+ * @property {string} [branch] - branch of the release, e.g. "4.0.x" or "main"
+ * @property {string} [date] - release date as an RFC 3339 string, if known
+ * @property {Advisory[]} [advisories] - security advisories affecting the release
+ */
+
+/**
+ * @typedef {Object} Advisory
+ * @property {string} id - Packagist advisory ID
+ * @property {string} package
+ * @property {string} title
+ * @property {string} [cve]
+ * @property {string} affected_versions - constraint of affected versions, e.g. ">=1.0,<1.2.3"
+ * @property {string} [link]
+ * @property {string} [severity]
+ * @property {string} [reported_at]
  */
 
 /**
@@ -34,10 +69,14 @@
  * @typedef {Object} ReleasesResponse
  * @property {string} package
  * @property {Release[]} releases
- */
-
-/**
- * @typedef {Record<string, any>} UpdateResponse
+ * @property {string} [project] - drupal.org project of Drupal packages, e.g. the parent project of sub-modules
+ * @property {string} [security_update] - newest security release the current constraint is below
+ * @property {Advisory[]} [advisories] - security advisories affecting the installed version or current constraint
+ * @property {string} [project_status] - status of the drupal.org project, e.g. "published" or "unsupported"
+ * @property {string} [project_warning] - warning about the project status, if the project is not published
+ * @property {boolean} [branch_unsupported] - true if the current constraint is not on a supported branch
+ * @property {boolean} [abandoned] - true if the package is abandoned according to its composer metadata
+ * @property {string} [replacement] - package suggested to replace the abandoned package
  */
 
 /**
@@ -68,6 +107,31 @@ export async function postJSON(url, body) {
   return data;
 }
 
+/**
+ * POST JSON to a URL and return the response body as text, e.g. a file that must keep its formatting.
+ * @param {string} url
+ * @param {Record<string, any>} body
+ * @returns {Promise<string>}
+ */
+export async function postJSONForText(url, body) {
+  const resp = await fetch(url, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  });
+  const text = await resp.text();
+  if (!resp.ok) {
+    let message = `HTTP ${resp.status}`;
+    try {
+      message = JSON.parse(text).error || message;
+    } catch (e) {
+      // not a JSON error response
+    }
+    throw new Error(message);
+  }
+  return text;
+}
+
 /**
  * GET JSON from a URL and return the parsed response.
  * @param {string} url
@@ -87,37 +151,103 @@ export async function getJSON(url) {
 /**
  * Call POST /api/parse with a composer.json object.
  * Returns Drupal and Composer packages separately.
+ * If a composer.lock object is given, packages include their installed versions.
  * @param {Record<string, any>} composerJSON
+ * @param {Record<string, any> | null} [composerLock]
  * @returns {Promise<ParseResponse>}
  */
-export async function parseComposer(composerJSON) {
-  return postJSON("/api/parse", { composer_json: composerJSON });
+export async function parseComposer(composerJSON, composerLock) {
+  /** @type {Record<string, any>} */
+  const body = { composer_json: composerJSON };
+  if (composerLock) body.composer_lock = composerLock;
+  return postJSON("/api/parse", body);
 }
 
+/**
+ * @typedef {Object} ReleaseOptions
+ * @property {string} [installed] - installed version, used to mark releases as installed, newer or older
+ * @property {string} [current] - current version constraint, used to mark releases that are already allowed
+ * @property {any} [repositories] - "repositories" section of the composer.json, consulted before drupal.org and Packagist
+ * @property {string} [minimumStability] - "minimum-stability" of the composer.json, the least stable releases offered
+ * @property {boolean} [preferStable] - "prefer-stable" of the composer.json, offer stable releases over newer less stable ones
+ * @property {string} [php] - platform PHP version, releases that cannot be installed on it are skipped
+ * @property {string} [core] - targeted Drupal core version, used to mark releases that do not support it
+ * @property {string} [maxUpdate] - only return releases up to this update type, e.g. "minor"
+ * @property {string} [pin] - pin strategy for version pins: "caret", "caret-patch", "tilde", "exact", "minimum" or "keep"
+ * @property {string} [mode] - "all" to return every published release instead of the latest per branch
+ * @property {string} [include] - comma-separated stabilities additionally returned in "all" mode, e.g. "beta,dev"
+ */
+
 /**
  * Call GET /api/releases?package=... to fetch releases for a package.
- * The server automatically routes to drupal.org or Packagist.
+ * The server automatically routes to drupal.org, Packagist or a custom repository.
  * @param {string} packageName - full composer package name (e.g. "drupal/gin" or "drush/drush")
+ * @param {ReleaseOptions} [options]
  * @returns {Promise<ReleasesResponse>}
  */
-export async function fetchReleases(packageName) {
-  return getJSON("/api/releases?package=" + encodeURIComponent(packageName));
+export async function fetchReleases(packageName, options = {}) {
+  let url = "/api/releases?package=" + encodeURIComponent(packageName);
+  if (options.installed) url += "&installed=" + encodeURIComponent(options.installed);
+  if (options.current) url += "&current=" + encodeURIComponent(options.current);
+  if (options.repositories) url += "&repositories=" + encodeURIComponent(JSON.stringify(options.repositories));
+  if (options.minimumStability) url += "&minimum-stability=" + encodeURIComponent(options.minimumStability);
+  if (options.preferStable) url += "&prefer-stable=true";
+  if (options.php) url += "&php=" + encodeURIComponent(options.php);
+  if (options.core) url += "&core=" + encodeURIComponent(options.core);
+  if (options.pin) url += "&pin=" + encodeURIComponent(options.pin);
+  if (options.maxUpdate) url += "&max-update=" + encodeURIComponent(options.maxUpdate);
+  if (options.mode) url += "&mode=" + encodeURIComponent(options.mode);
+  if (options.include) url += "&include=" + encodeURIComponent(options.include);
+  return getJSON(url);
 }
 
 /**
  * Call POST /api/update to apply version changes to a composer.json.
- * @param {Record<string, any>} composerJSON
+ * The composer.json is sent and returned as text, so that only the changed constraints differ.
+ * @param {string} composerText - contents of the composer.json file
  * @param {Record<string, string>} versions - map of package name to new version
- * @returns {Promise<UpdateResponse>}
+ * @param {Record<string, string>} [replacements] - map of abandoned package name to the package replacing it
+ * @returns {Promise<string>} contents of the updated composer.json file
  */
-export async function updateComposer(composerJSON, versions) {
-  return postJSON("/api/update", { composer_json: composerJSON, versions });
+export async function updateComposer(composerText, versions, replacements = {}) {
+  return postJSONForText("/api/update", { composer_json: composerText, versions, replacements });
 }
 
 // =============================================================================
 // Pure Helpers
 // =============================================================================
 
+/**
+ * Find the names of packages carrying patches that are changed by a version map.
+ * Their patches may no longer apply and should be reviewed.
+ * @param {{name: string, patches?: Patch[]}[]} packages
+ * @param {Record<string, string>} versions - map of package name to new version
+ * @returns {string[]} sorted package names
+ */
+export function findPatchedUpdates(packages, versions) {
+  return packages
+    .filter(pkg => pkg.name in versions && pkg.patches && pkg.patches.length > 0)
+    .map(pkg => pkg.name)
+    .sort();
+}
+
+/**
+ * Summarize how far a package is behind, based on the update type of its releases.
+ * Returns e.g. "3 majors, 1 minor behind", or "" if no release is an update.
+ * @param {Release[]} releases
+ * @returns {string}
+ */
+export function summarizeUpdates(releases) {
+  /** @type {[string, string, string][]} update type, singular and plural */
+  const types = [["major", "major", "majors"], ["minor", "minor", "minors"], ["patch", "patch", "patches"], ["pre-release", "pre-release", "pre-releases"]];
+  const parts = [];
+  for (const [type, singular, plural] of types) {
+    const count = releases.filter(r => r.update === type).length;
+    if (count > 0) parts.push(count + " " + (count === 1 ? singular : plural));
+  }
+  return parts.length > 0 ? parts.join(", ") + " behind" : "";
+}
+
 /**
  * Build a version map from packages and their selected values.
  * Only includes entries where the selected version differs from the current one.
@@ -135,18 +265,47 @@ export function buildVersionMap(packages) {
   return versions;
 }
 
+/** Prefix of selected versions that replace an abandoned package by the package following it. */
+export const REPLACE_PREFIX = "replace:";
+
+/**
+ * Split a version map into new versions and replacements of abandoned packages.
+ * Versions starting with REPLACE_PREFIX select the package to replace a package with.
+ * @param {Record<string, string>} selected - map of package name to selected version
+ * @returns {{versions: Record<string, string>, replacements: Record<string, string>}}
+ */
+export function splitReplacements(selected) {
+  /** @type {Record<string, string>} */
+  const versions = {};
+  /** @type {Record<string, string>} */
+  const replacements = {};
+  for (const [pkg, version] of Object.entries(selected)) {
+    if (version.startsWith(REPLACE_PREFIX)) {
+      replacements[pkg] = version.slice(REPLACE_PREFIX.length);
+    } else {
+      versions[pkg] = version;
+    }
+  }
+  return { versions, replacements };
+}
+
 /**
  * Build a list of composer commands that apply the given requirements.
  * Returns one "composer require ... --no-update" per package, followed by "composer update".
+ * Development requirements are passed with "--dev".
  * @param {Record<string, string>} versions - map of package name to version constraint
+ * @param {Record<string, string>} [devVersions] - map of package name to version constraint for require-dev
  * @returns {string[]}
  */
-export function buildComposerCommands(versions) {
+export function buildComposerCommands(versions, devVersions = {}) {
   /** @type {string[]} */
   const commands = [];
   for (const [pkg, version] of Object.entries(versions)) {
     commands.push(`composer require "${pkg}:${version}" --no-update`);
   }
+  for (const [pkg, version] of Object.entries(devVersions)) {
+    commands.push(`composer require --dev "${pkg}:${version}" --no-update`);
+  }
   if (commands.length > 0) {
     commands.push("composer update --with-all-dependencies");
   }
@@ -1,5 +1,5 @@
 import { describe, it, expect, vi, beforeEach } from "vitest";
-import { postJSON, getJSON, parseComposer, fetchReleases, updateComposer, buildVersionMap, buildComposerCommands, buildDryRunCommand } from "./api.js";
+import { postJSON, postJSONForText, getJSON, parseComposer, fetchReleases, updateComposer, buildVersionMap, splitReplacements, buildComposerCommands, buildDryRunCommand, findPatchedUpdates, summarizeUpdates } from "./api.js";
 
 // =============================================================================
 // Mock fetch
@@ -11,6 +11,7 @@ function mockFetch(status, body) {
       ok: status >= 200 && status < 300,
       status,
       json: () => Promise.resolve(body),
+      text: () => Promise.resolve(typeof body === "string" ? body : JSON.stringify(body)),
     })
   );
 }
@@ -44,6 +45,33 @@ describe("postJSON", () => {
   });
 });
 
+// =============================================================================
+// postJSONForText
+// =============================================================================
+
+describe("postJSONForText", () => {
+  it("sends a POST request and returns the response text as is", async () => {
+    global.fetch = mockFetch(200, '{\n  "require": {}\n}\n');
+
+    const text = await postJSONForText("/test", { key: "value" });
+
+    expect(text).toBe('{\n  "require": {}\n}\n');
+    expect(global.fetch.mock.calls[0][1].body).toBe(JSON.stringify({ key: "value" }));
+  });
+
+  it("throws the error of a JSON error response", async () => {
+    global.fetch = mockFetch(400, { error: "bad request" });
+
+    await expect(postJSONForText("/test", {})).rejects.toThrow("bad request");
+  });
+
+  it("throws the status of other error responses", async () => {
+    global.fetch = mockFetch(502, "Bad Gateway");
+
+    await expect(postJSONForText("/test", {})).rejects.toThrow("HTTP 502");
+  });
+});
+
 // =============================================================================
 // getJSON
 // =============================================================================
@@ -89,8 +117,19 @@ describe("parseComposer", () => {
     expect(data.composer_packages[0].name).toBe("drush/drush");
 
     // Verify the request was sent to the right URL
-    const [url] = global.fetch.mock.calls[0];
+    const [url, opts] = global.fetch.mock.calls[0];
     expect(url).toBe("/api/parse");
+    expect(JSON.parse(opts.body)).not.toHaveProperty("composer_lock");
+  });
+
+  it("sends the composer_lock when given", async () => {
+    global.fetch = mockFetch(200, { drupal_packages: [], composer_packages: [] });
+
+    const lock = { packages: [{ name: "drupal/gin", version: "5.0.3" }] };
+    await parseComposer({ require: { "drupal/gin": "^5.0" } }, lock);
+
+    const [, opts] = global.fetch.mock.calls[0];
+    expect(JSON.parse(opts.body).composer_lock).toEqual(lock);
   });
 });
 
@@ -129,6 +168,79 @@ describe("fetchReleases", () => {
     const [url] = global.fetch.mock.calls[0];
     expect(url).toBe("/api/releases?package=drush%2Fdrush");
   });
+
+  it("passes the installed version", async () => {
+    global.fetch = mockFetch(200, { package: "drush/drush", releases: [] });
+
+    await fetchReleases("drush/drush", { installed: "12.5.6" });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=drush%2Fdrush&installed=12.5.6");
+  });
+
+  it("passes the current constraint", async () => {
+    global.fetch = mockFetch(200, { package: "drush/drush", releases: [] });
+
+    await fetchReleases("drush/drush", { current: "^12 || ^13" });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=drush%2Fdrush&current=%5E12%20%7C%7C%20%5E13");
+  });
+
+  it("passes the repositories as JSON", async () => {
+    global.fetch = mockFetch(200, { package: "acme/lib", releases: [] });
+
+    const repositories = [{ type: "composer", url: "https://satis.example.com" }];
+    await fetchReleases("acme/lib", { repositories });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=acme%2Flib&repositories=" + encodeURIComponent(JSON.stringify(repositories)));
+  });
+
+  it("passes the stability settings", async () => {
+    global.fetch = mockFetch(200, { package: "acme/lib", releases: [] });
+
+    await fetchReleases("acme/lib", { minimumStability: "RC", preferStable: true });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=acme%2Flib&minimum-stability=RC&prefer-stable=true");
+  });
+
+  it("passes the platform PHP version", async () => {
+    global.fetch = mockFetch(200, { package: "acme/lib", releases: [] });
+
+    await fetchReleases("acme/lib", { php: "8.1.0" });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=acme%2Flib&php=8.1.0");
+  });
+
+  it("passes the pin strategy", async () => {
+    global.fetch = mockFetch(200, { package: "acme/lib", releases: [] });
+
+    await fetchReleases("acme/lib", { pin: "keep" });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=acme%2Flib&pin=keep");
+  });
+
+  it("passes the release mode and included stabilities", async () => {
+    global.fetch = mockFetch(200, { package: "acme/lib", releases: [] });
+
+    await fetchReleases("acme/lib", { mode: "all", include: "beta,dev" });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=acme%2Flib&mode=all&include=beta%2Cdev");
+  });
+
+  it("passes the maximum update type", async () => {
+    global.fetch = mockFetch(200, { package: "acme/lib", releases: [] });
+
+    await fetchReleases("acme/lib", { maxUpdate: "minor" });
+
+    const [url] = global.fetch.mock.calls[0];
+    expect(url).toBe("/api/releases?package=acme%2Flib&max-update=minor");
+  });
 });
 
 // =============================================================================
@@ -136,25 +248,47 @@ describe("fetchReleases", () => {
 // =============================================================================
 
 describe("updateComposer", () => {
-  it("calls /api/update with composer_json and versions", async () => {
-    const mockResponse = {
-      composer_json: { require: { "drupal/gin": "^6.0", "drush/drush": "^13" } },
-    };
-    global.fetch = mockFetch(200, mockResponse);
+  it("calls /api/update with the composer.json text and returns the updated text", async () => {
+    const composerText = '{\n  "require": {\n    "drupal/gin": "^5.0",\n    "drush/drush": "^12"\n  }\n}\n';
+    const updatedText = '{\n  "require": {\n    "drupal/gin": "^6.0",\n    "drush/drush": "^13"\n  }\n}\n';
+    global.fetch = mockFetch(200, updatedText);
 
-    const data = await updateComposer(
-      { require: { "drupal/gin": "^5.0", "drush/drush": "^12" } },
-      { "drupal/gin": "^6.0", "drush/drush": "^13" },
-    );
+    const text = await updateComposer(composerText, { "drupal/gin": "^6.0", "drush/drush": "^13" });
 
-    expect(data.composer_json.require["drupal/gin"]).toBe("^6.0");
-    expect(data.composer_json.require["drush/drush"]).toBe("^13");
+    expect(text).toBe(updatedText);
 
     const [url, opts] = global.fetch.mock.calls[0];
     expect(url).toBe("/api/update");
     const body = JSON.parse(opts.body);
+    expect(body.composer_json).toBe(composerText);
     expect(body.versions).toEqual({ "drupal/gin": "^6.0", "drush/drush": "^13" });
   });
+
+  it("passes replacements of abandoned packages", async () => {
+    global.fetch = mockFetch(200, '{"require": {"acme/new": "^2.0"}}');
+
+    await updateComposer('{"require": {"acme/old": "^1.0"}}', {}, { "acme/old": "acme/new" });
+
+    const body = JSON.parse(global.fetch.mock.calls[0][1].body);
+    expect(body.replacements).toEqual({ "acme/old": "acme/new" });
+  });
+});
+
+// =============================================================================
+// splitReplacements
+// =============================================================================
+
+describe("splitReplacements", () => {
+  it("separates replacements from new versions", () => {
+    expect(splitReplacements({ "drupal/gin": "^6.0", "acme/old": "replace:acme/new" })).toEqual({
+      versions: { "drupal/gin": "^6.0" },
+      replacements: { "acme/old": "acme/new" },
+    });
+  });
+
+  it("returns empty maps for no selections", () => {
+    expect(splitReplacements({})).toEqual({ versions: {}, replacements: {} });
+  });
 });
 
 // =============================================================================
@@ -226,8 +360,19 @@ describe("buildComposerCommands", () => {
     ]);
   });
 
+  it("passes --dev for require-dev packages", () => {
+    const commands = buildComposerCommands({ "drupal/gin": "^6.0" }, { "drupal/devel": "^5.0" });
+
+    expect(commands).toEqual([
+      'composer require "drupal/gin:^6.0" --no-update',
+      'composer require --dev "drupal/devel:^5.0" --no-update',
+      "composer update --with-all-dependencies",
+    ]);
+  });
+
   it("returns empty array when no versions given", () => {
     expect(buildComposerCommands({})).toEqual([]);
+    expect(buildComposerCommands({}, {})).toEqual([]);
   });
 });
 
@@ -256,3 +401,57 @@ describe("buildDryRunCommand", () => {
     expect(buildDryRunCommand({})).toBe("");
   });
 });
+
+// =============================================================================
+// findPatchedUpdates
+// =============================================================================
+
+describe("findPatchedUpdates", () => {
+  it("returns patched packages that are updated", () => {
+    const packages = [
+      { name: "drupal/webform", patches: [{ description: "b", url: "b.patch" }] },
+      { name: "drupal/gin", patches: [{ description: "a", url: "a.patch" }] },
+      { name: "drupal/token", patches: [] },
+      { name: "drush/drush" },
+    ];
+    const versions = { "drupal/gin": "^6.0", "drupal/webform": "^7.0", "drupal/token": "^2.0", "drush/drush": "^13" };
+
+    expect(findPatchedUpdates(packages, versions)).toEqual(["drupal/gin", "drupal/webform"]);
+  });
+
+  it("ignores patched packages that are not updated", () => {
+    const packages = [{ name: "drupal/gin", patches: [{ description: "a", url: "a.patch" }] }];
+
+    expect(findPatchedUpdates(packages, {})).toEqual([]);
+  });
+});
+
+// =============================================================================
+// summarizeUpdates
+// =============================================================================
+
+describe("summarizeUpdates", () => {
+  it("counts releases per update type", () => {
+    const releases = [
+      { name: "", version: "13.0.0", version_pin: "^13.0", update: "major" },
+      { name: "", version: "12.0.0", version_pin: "^12.0", update: "major" },
+      { name: "", version: "11.0.0", version_pin: "^11.0", update: "major" },
+      { name: "", version: "10.5.0", version_pin: "^10.5", update: "minor" },
+      { name: "", version: "10.4.0", version_pin: "^10.4", update: "none" },
+    ];
+    expect(summarizeUpdates(releases)).toBe("3 majors, 1 minor behind");
+  });
+
+  it("pluralizes patches", () => {
+    const releases = [
+      { name: "", version: "1.0.2", version_pin: "^1.0", update: "patch" },
+      { name: "", version: "1.0.1", version_pin: "^1.0", update: "patch" },
+    ];
+    expect(summarizeUpdates(releases)).toBe("2 patches behind");
+  });
+
+  it("returns an empty string when there are no updates", () => {
+    expect(summarizeUpdates([])).toBe("");
+    expect(summarizeUpdates([{ name: "", version: "1.0.0", version_pin: "^1.0", update: "none" }])).toBe("");
+  });
+});
@@ -1,6 +1,7 @@
-import { parseComposer, fetchReleases, updateComposer, buildVersionMap, buildComposerCommands, buildDryRunCommand } from "./api.js";
+import { parseComposer, fetchReleases, updateComposer, buildVersionMap, splitReplacements, REPLACE_PREFIX, buildComposerCommands, buildDryRunCommand, findPatchedUpdates, summarizeUpdates } from "./api.js";
 
 /** @typedef {import("./api.js").Release} Release */
+/** @typedef {import("./api.js").Patch} Patch */
 /** @typedef {import("./api.js").VersionSelection} VersionSelection */
 
 /**
@@ -8,14 +9,26 @@ import { parseComposer, fetchReleases, updateComposer, buildVersionMap, buildCom
  * @property {string} name
  * @property {string} module
  * @property {string} version
+ * @property {string} [section]
+ * @property {string} [installed]
+ * @property {boolean} [abandoned] - true if the package is abandoned according to composer.lock
+ * @property {string} [replacement] - package suggested to replace the abandoned package
+ * @property {Patch[]} [patches]
  * @property {Release[]} releases
+ * @property {string} [securityUpdate] - newest security release the current constraint is below
+ * @property {import("./api.js").Advisory[]} [advisories] - security advisories affecting the current version
+ * @property {import("./api.js").ReleasesResponse} [project] - project status of the last release fetch
  */
 
 /**
  * @typedef {Object} CoreState
- * @property {{name: string, version: string}[]} packages
+ * @property {{name: string, version: string, patches?: Patch[]}[]} packages
  * @property {string} version
+ * @property {string} [installed]
  * @property {Release[]} releases
+ * @property {string} [securityUpdate] - newest security release the current constraint is below
+ * @property {import("./api.js").Advisory[]} [advisories] - security advisories affecting the current version
+ * @property {import("./api.js").ReleasesResponse} [project] - project status of the last release fetch
  */
 
 // =============================================================================
@@ -28,6 +41,10 @@ let coreState = null;
 let drupalPackages = [];
 /** @type {PackageState[]} */
 let composerPackages = [];
+/** @type {Record<string, any> | null} The loaded composer.lock, if any. */
+let composerLock = null;
+/** @type {import("./api.js").ReleaseOptions} Options from the composer.json shared by all release fetches. */
+let sharedReleaseOptions = {};
 /** Whether the textarea is currently editable. */
 let editing = false;
 
@@ -53,6 +70,8 @@ const commandsDryrunOutput = /** @type {HTMLPreElement} */ (document.getElementB
 const btnCopyDryrun = /** @type {HTMLButtonElement} */ (document.getElementById("btn-copy-dryrun"));
 const btnCopyJson = /** @type {HTMLButtonElement} */ (document.getElementById("btn-copy-json"));
 const tabPackages = /** @type {HTMLButtonElement} */ (document.querySelector('[data-tab="tab-packages"]'));
+const pinSelect = /** @type {HTMLSelectElement} */ (document.getElementById("pin-strategy"));
+const modeSelect = /** @type {HTMLSelectElement} */ (document.getElementById("release-mode"));
 
 // =============================================================================
 // Tabs
@@ -140,7 +159,7 @@ async function loadComposer() {
 
   let parsed;
   try {
-    parsed = await parseComposer(composerJSON);
+    parsed = await parseComposer(composerJSON, composerLock);
   } catch (e) {
     setStatus("Error parsing: " + /** @type {Error} */ (e).message, true);
     return;
@@ -150,8 +169,9 @@ async function loadComposer() {
   const corePkgs = parsed.core_packages || [];
   if (corePkgs.length > 0) {
     coreState = {
-      packages: corePkgs.map(p => ({ name: p.name, version: p.version })),
+      packages: corePkgs.map(p => ({ name: p.name, version: p.version, patches: p.patches })),
       version: corePkgs[0].version,
+      installed: corePkgs[0].installed,
       releases: [],
     };
   } else {
@@ -162,6 +182,11 @@ async function loadComposer() {
     name: pkg.name,
     module: pkg.module,
     version: pkg.version,
+    section: pkg.section,
+    installed: pkg.installed,
+    abandoned: pkg.abandoned,
+    replacement: pkg.replacement,
+    patches: pkg.patches,
     releases: /** @type {Release[]} */ ([]),
   }));
 
@@ -169,13 +194,27 @@ async function loadComposer() {
     name: pkg.name,
     module: pkg.module,
     version: pkg.version,
+    section: pkg.section,
+    installed: pkg.installed,
+    abandoned: pkg.abandoned,
+    replacement: pkg.replacement,
+    patches: pkg.patches,
     releases: /** @type {Release[]} */ ([]),
   }));
 
   setStatus("Fetching releases...");
   renderTable();
 
-  // Fetch releases for all packages in parallel
+  // Fetch releases for all packages in parallel, honoring custom repositories, stability settings, the platform PHP version, the pin style and the release mode
+  sharedReleaseOptions = {
+    repositories: composerJSON.repositories,
+    minimumStability: composerJSON["minimum-stability"],
+    preferStable: composerJSON["prefer-stable"],
+    php: composerJSON.config?.platform?.php,
+    pin: pinSelect.value,
+    mode: modeSelect.value || undefined,
+    include: modeSelect.selectedOptions[0]?.dataset.include,
+  };
   /** @type {Promise<void>[]} */
   const fetches = [];
 
@@ -183,8 +222,15 @@ async function loadComposer() {
   if (coreState) {
     fetches.push((async () => {
       try {
-        const data = await fetchReleases(coreState.packages[0].name);
+        const data = await fetchReleases(coreState.packages[0].name, {
+          installed: coreState.installed,
+          current: coreState.version,
+          ...sharedReleaseOptions,
+        });
         coreState.releases = data.releases || [];
+        coreState.securityUpdate = data.security_update;
+        coreState.advisories = data.advisories;
+        coreState.project = data;
       } catch (e) {
         coreState.releases = [];
       }
@@ -195,8 +241,16 @@ async function loadComposer() {
   for (const pkg of allPackages()) {
     fetches.push((async () => {
       try {
-        const data = await fetchReleases(pkg.name);
+        const data = await fetchReleases(pkg.name, {
+          installed: pkg.installed,
+          current: pkg.version,
+          ...sharedReleaseOptions,
+        });
         pkg.releases = data.releases || [];
+        pkg.securityUpdate = data.security_update;
+        pkg.advisories = data.advisories;
+        pkg.project = data;
+        if (data.project) pkg.module = data.project;
       } catch (e) {
         pkg.releases = [];
       }
@@ -211,13 +265,11 @@ async function loadComposer() {
 
 /** Build a version map from the dropdowns and call the update API. */
 async function applyVersions() {
-  const text = textarea.value.trim();
-  if (!text) return;
+  const text = textarea.value;
+  if (!text.trim()) return;
 
-  /** @type {Record<string, any>} */
-  let composerJSON;
   try {
-    composerJSON = JSON.parse(text);
+    JSON.parse(text);
   } catch (e) {
     setStatus("Invalid JSON in textarea.", true);
     return;
@@ -246,9 +298,10 @@ async function applyVersions() {
     }
   }
 
-  const versions = buildVersionMap(withSelections);
+  const selected = buildVersionMap(withSelections);
+  const count = Object.keys(selected).length;
 
-  if (Object.keys(versions).length === 0) {
+  if (count === 0) {
     setStatus("No version changes selected.");
     return;
   }
@@ -256,15 +309,46 @@ async function applyVersions() {
   setStatus("Applying updates...");
 
   try {
-    const data = await updateComposer(composerJSON, versions);
-    textarea.value = JSON.stringify(data, null, 4);
-    setStatus("Updated " + Object.keys(versions).length + " package(s). Reloading table...");
+    const { versions, replacements } = splitReplacements(selected);
+    textarea.value = await updateComposer(text, versions, replacements);
+    setStatus("Updated " + count + " package(s). Reloading table...");
+    const patched = findPatchedUpdates([...allPackages(), ...(coreState ? coreState.packages : [])], versions);
     await loadComposer();
+    if (patched.length > 0) {
+      setStatus("Updated " + count + " package(s). Review the patches of: " + patched.join(", "));
+    }
   } catch (e) {
     setStatus("Error applying updates: " + /** @type {Error} */ (e).message, true);
   }
 }
 
+/**
+ * Re-fetch the releases of all Drupal packages, marking those that do not support the given core version.
+ * The labels of the existing dropdowns are updated in place, keeping their selections.
+ * @param {string} core - targeted Drupal core version, or "" to remove the marks
+ */
+async function checkCoreCompatibility(core) {
+  await Promise.all(drupalPackages.map(async pkg => {
+    try {
+      const data = await fetchReleases(pkg.name, {
+        installed: pkg.installed,
+        current: pkg.version,
+        ...sharedReleaseOptions,
+        core,
+      });
+      pkg.releases = data.releases || [];
+    } catch (e) {
+      return;
+    }
+    const select = /** @type {HTMLSelectElement | null} */ (document.getElementById("select-" + pkg.name));
+    if (!select) return;
+    for (const option of Array.from(select.options)) {
+      const release = pkg.releases.find(r => r.version_pin === option.value);
+      if (release && option.index > 0) option.textContent = releaseLabel(release);
+    }
+  }));
+}
+
 // =============================================================================
 // Revert & Set All to Latest
 // =============================================================================
@@ -369,6 +453,179 @@ function renderSectionHeader(title, onSetLatest) {
   packagesBody.appendChild(row);
 }
 
+/**
+ * Append the installed version (from composer.lock) below the current version constraint.
+ * @param {HTMLTableCellElement} cell
+ * @param {string} [installed]
+ */
+function appendInstalled(cell, installed) {
+  if (!installed) return;
+  const div = document.createElement("div");
+  div.className = "installed";
+  div.textContent = "installed: " + installed;
+  cell.appendChild(div);
+}
+
+/**
+ * Append how far a package is behind (e.g. "3 majors behind") below the current version constraint.
+ * @param {HTMLTableCellElement} cell
+ * @param {Release[]} releases
+ */
+function appendUpdateSummary(cell, releases) {
+  const summary = summarizeUpdates(releases);
+  if (!summary) return;
+  const div = document.createElement("div");
+  div.className = "update-summary";
+  div.textContent = summary;
+  cell.appendChild(div);
+}
+
+/**
+ * Append a tag listing the composer-patches applied to a package.
+ * @param {HTMLTableCellElement} cell
+ * @param {Patch[]} [patches]
+ */
+function appendPatches(cell, patches) {
+  if (!patches || patches.length === 0) return;
+  const tag = document.createElement("span");
+  tag.className = "tag-patched";
+  tag.textContent = "patched (" + patches.length + ")";
+  tag.title = patches.map(p => p.description + ": " + p.url).join("\n");
+  cell.appendChild(tag);
+}
+
+/**
+ * Append a tag highlighting a package whose current constraint is below a security release.
+ * @param {HTMLTableCellElement} cell
+ * @param {string} [securityUpdate]
+ */
+function appendSecurity(cell, securityUpdate) {
+  if (!securityUpdate) return;
+  const tag = document.createElement("span");
+  tag.className = "tag-security";
+  tag.textContent = "security";
+  tag.title = "The current constraint allows versions below security release " + securityUpdate + ".";
+  cell.appendChild(tag);
+}
+
+/**
+ * Append a tag listing the security advisories affecting the current version of a package.
+ * @param {HTMLTableCellElement} cell
+ * @param {import("./api.js").Advisory[]} [advisories]
+ */
+function appendAdvisories(cell, advisories) {
+  if (!advisories || advisories.length === 0) return;
+  const tag = document.createElement("span");
+  tag.className = "tag-advisory";
+  tag.textContent = "advisories (" + advisories.length + ")";
+  tag.title = advisories.map(a => (a.cve ? a.cve + ": " : "") + a.title + " (affected: " + a.affected_versions + ")").join("\n");
+  cell.appendChild(tag);
+}
+
+/**
+ * Append tags warning about a drupal.org project that is not published (e.g. unsupported or insecure),
+ * or a current constraint that is not on a supported branch.
+ * @param {HTMLTableCellElement} cell
+ * @param {import("./api.js").ReleasesResponse} [project]
+ */
+function appendProjectWarning(cell, project) {
+  if (!project) return;
+  if (project.project_warning) {
+    const tag = document.createElement("span");
+    tag.className = "tag-project";
+    tag.textContent = project.project_status || "warning";
+    tag.title = project.project_warning;
+    cell.appendChild(tag);
+  }
+  if (project.branch_unsupported) {
+    const tag = document.createElement("span");
+    tag.className = "tag-project";
+    tag.textContent = "unsupported branch";
+    tag.title = "The current constraint is not on a branch supported by the maintainers.";
+    cell.appendChild(tag);
+  }
+}
+
+/**
+ * Append a tag marking an abandoned package, naming its suggested replacement if any.
+ * The package is abandoned if its composer metadata or composer.lock says so.
+ * @param {HTMLTableCellElement} cell
+ * @param {PackageState} pkg
+ */
+function appendAbandoned(cell, pkg) {
+  const abandoned = pkg.project?.abandoned || pkg.abandoned;
+  if (!abandoned) return;
+  const replacement = abandonedReplacement(pkg);
+  const tag = document.createElement("span");
+  tag.className = "tag-project";
+  tag.textContent = "abandoned";
+  tag.title = replacement ? "The package is abandoned, use " + replacement + " instead." : "The package is abandoned.";
+  cell.appendChild(tag);
+}
+
+/**
+ * Return the package suggested to replace an abandoned package, or "" if there is none.
+ * @param {PackageState} pkg
+ * @returns {string}
+ */
+function abandonedReplacement(pkg) {
+  if (pkg.project?.abandoned) return pkg.project.replacement || "";
+  return pkg.replacement || "";
+}
+
+/**
+ * Create a hidden warning, shown when a patched package is set to a new version.
+ * @param {Patch[]} [patches]
+ * @returns {HTMLDivElement | null}
+ */
+function patchWarning(patches) {
+  if (!patches || patches.length === 0) return null;
+  const div = document.createElement("div");
+  div.className = "patch-warning";
+  div.textContent = "Patches may no longer apply, review them before updating.";
+  div.hidden = true;
+  return div;
+}
+
+/**
+ * Build the label of a release option in a version dropdown.
+ * @param {Release} release
+ * @returns {string}
+ */
+function releaseLabel(release) {
+  let label = release.version_pin;
+  if (release.core_compatibility) {
+    label += "  (" + release.version + ", core: " + release.core_compatibility + ")";
+  } else if (release.version_pin !== "^" + release.version) {
+    label += "  (" + release.version + ")";
+  }
+  if (release.php) {
+    label += "  [php " + release.php + "]";
+  }
+  if (release.date) {
+    label += "  [" + release.date.slice(0, 10) + "]";
+  }
+  if (release.relation === "installed") {
+    label += "  [installed]";
+  }
+  if (release.security) {
+    label += "  [security]";
+  }
+  if (release.advisories && release.advisories.length > 0) {
+    label += "  [" + release.advisories.length + " advisories]";
+  }
+  if (release.update && release.update !== "none") {
+    label += "  [" + release.update + " update]";
+  }
+  if (release.allowed) {
+    label += "  [already allowed]";
+  }
+  if (release.core_incompatible) {
+    label += "  [incompatible with selected core]";
+  }
+  return label;
+}
+
 /** Render the special Drupal Core row with a single dropdown for all core packages. */
 function renderCoreRow() {
   if (!coreState) return;
@@ -389,12 +646,19 @@ function renderCoreRow() {
   packageList.style.color = "#666";
   packageList.textContent = coreState.packages.map(p => p.name).join(", ");
   nameCell.appendChild(packageList);
+  const corePatches = coreState.packages.flatMap(p => p.patches || []);
+  appendPatches(nameCell, corePatches);
+  appendSecurity(nameCell, coreState.securityUpdate);
+  appendAdvisories(nameCell, coreState.advisories);
+  appendProjectWarning(nameCell, coreState.project);
   row.appendChild(nameCell);
 
   // Current version
   const versionCell = document.createElement("td");
   versionCell.className = "col-version";
   versionCell.textContent = coreState.version;
+  appendInstalled(versionCell, coreState.installed);
+  appendUpdateSummary(versionCell, coreState.releases);
   row.appendChild(versionCell);
 
   // Drupal Core column (empty for the core row itself)
@@ -416,18 +680,20 @@ function renderCoreRow() {
     for (const release of coreState.releases) {
       const option = document.createElement("option");
       option.value = release.version_pin;
-      let label = release.version_pin;
-      if (release.core_compatibility) {
-        label += "  (" + release.version + ", core: " + release.core_compatibility + ")";
-      } else if (release.version_pin !== "^" + release.version) {
-        label += "  (" + release.version + ")";
-      }
-      option.textContent = label;
+      option.textContent = releaseLabel(release);
       select.appendChild(option);
     }
 
-    select.addEventListener("change", () => updatePackagesTabDirty());
+    const warning = patchWarning(corePatches);
+    const coreVersion = coreState.version;
+    select.addEventListener("change", () => {
+      if (warning) warning.hidden = select.value === coreVersion;
+      updatePackagesTabDirty();
+      const target = coreState && coreState.releases.find(r => r.version_pin === select.value);
+      checkCoreCompatibility(target ? target.version : "");
+    });
     selectCell.appendChild(select);
+    if (warning) selectCell.appendChild(warning);
   } else {
     selectCell.textContent = "Loading...";
   }
@@ -456,12 +722,25 @@ function renderPackageRow(pkg, isDrupal) {
   link.rel = "noopener noreferrer";
   link.textContent = pkg.name;
   nameCell.appendChild(link);
+  if (pkg.section === "require-dev") {
+    const devTag = document.createElement("span");
+    devTag.className = "tag-dev";
+    devTag.textContent = "dev";
+    nameCell.appendChild(devTag);
+  }
+  appendPatches(nameCell, pkg.patches);
+  appendSecurity(nameCell, pkg.securityUpdate);
+  appendAdvisories(nameCell, pkg.advisories);
+  appendProjectWarning(nameCell, pkg.project);
+  appendAbandoned(nameCell, pkg);
   row.appendChild(nameCell);
 
   // Current version
   const versionCell = document.createElement("td");
   versionCell.className = "col-version";
   versionCell.textContent = pkg.version;
+  appendInstalled(versionCell, pkg.installed);
+  appendUpdateSummary(versionCell, pkg.releases);
   row.appendChild(versionCell);
 
   // Drupal Core column (Drupal packages only — shows core_compatibility)
@@ -491,23 +770,31 @@ function renderPackageRow(pkg, isDrupal) {
     for (const release of pkg.releases) {
       const option = document.createElement("option");
       option.value = release.version_pin;
-      let label = release.version_pin;
-      if (release.core_compatibility) {
-        label += "  (" + release.version + ", core: " + release.core_compatibility + ")";
-      } else if (release.version_pin !== "^" + release.version) {
-        label += "  (" + release.version + ")";
-      }
-      option.textContent = label;
+      option.textContent = releaseLabel(release);
       select.appendChild(option);
     }
 
-    // Update dirty indicator and core cell when user changes selection
+    const replacement = abandonedReplacement(pkg);
+    if (replacement) {
+      const replaceOption = document.createElement("option");
+      replaceOption.value = REPLACE_PREFIX + replacement;
+      replaceOption.textContent = "Replace with " + replacement;
+      select.appendChild(replaceOption);
+    }
+
+    const warning = patchWarning(pkg.patches);
+
+    // Update dirty indicator, core cell and patch warning when user changes selection
     select.addEventListener("change", () => {
       updateCoreCell(select.value);
+      if (warning) warning.hidden = select.value === pkg.version;
       updatePackagesTabDirty();
     });
 
     selectCell.appendChild(select);
+    if (warning) selectCell.appendChild(warning);
+  } else if (pkg.project && pkg.project.project_warning) {
+    selectCell.textContent = "No releases: " + pkg.project.project_warning;
   } else {
     selectCell.textContent = "Loading...";
   }
@@ -577,7 +864,9 @@ function updateCommands() {
 
   /** @type {Record<string, string>} */
   const require = composerJSON.require || {};
-  const commands = buildComposerCommands(require);
+  /** @type {Record<string, string>} */
+  const requireDev = composerJSON["require-dev"] || {};
+  const commands = buildComposerCommands(require, requireDev);
   const dryRun = buildDryRunCommand(require);
 
   if (commands.length === 0) {
@@ -637,6 +926,43 @@ wireCopyButton(btnCopyJson, () => textarea.value);
 // File Handling
 // =============================================================================
 
+/**
+ * Read a list of dropped or selected files.
+ * Files named composer.lock are loaded as the lock file, any other file as composer.json.
+ * @param {FileList} files
+ */
+function handleFiles(files) {
+  for (const file of Array.from(files)) {
+    if (file.name.endsWith(".lock")) {
+      handleLockFile(file);
+    } else {
+      handleFile(file);
+    }
+  }
+}
+
+/**
+ * Read a composer.lock File object and reload the packages with their installed versions.
+ * @param {File} file
+ */
+function handleLockFile(file) {
+  const reader = new FileReader();
+  reader.onload = function () {
+    try {
+      composerLock = JSON.parse(/** @type {string} */ (reader.result));
+    } catch (e) {
+      setStatus("Invalid JSON in composer.lock.", true);
+      return;
+    }
+    if (textarea.value.trim()) {
+      loadComposer();
+    } else {
+      setStatus("Loaded composer.lock. Now load a composer.json.");
+    }
+  };
+  reader.readAsText(file);
+}
+
 /**
  * Read a File object into the textarea and trigger loading.
  * @param {File} file
@@ -665,7 +991,7 @@ dropZone.addEventListener("click", () => {
   if (!editing) fileInput.click();
 });
 fileInput.addEventListener("change", () => {
-  if (fileInput.files && fileInput.files.length > 0) handleFile(fileInput.files[0]);
+  if (fileInput.files && fileInput.files.length > 0) handleFiles(fileInput.files);
 });
 
 // Edit toggle button
@@ -690,6 +1016,16 @@ btnApply.addEventListener("click", () => applyVersions());
 // Revert button — reset all dropdowns to their current version
 btnRevert.addEventListener("click", () => revertVersions());
 
+// Pin style — re-fetch releases so that their version pins follow the new style
+pinSelect.addEventListener("change", () => {
+  if (!editing && textarea.value.trim()) loadComposer();
+});
+
+// Release mode — re-fetch releases to offer the latest per branch or all of them
+modeSelect.addEventListener("change", () => {
+  if (!editing && textarea.value.trim()) loadComposer();
+});
+
 // Drag and drop (disabled while editing)
 dropZone.addEventListener("dragover", (e) => {
   e.preventDefault();
@@ -702,6 +1038,6 @@ dropZone.addEventListener("drop", (e) => {
   e.preventDefault();
   dropZone.classList.remove("dragover");
   if (!editing && e.dataTransfer && e.dataTransfer.files.length > 0) {
-    handleFile(e.dataTransfer.files[0]);
+    handleFiles(e.dataTransfer.files);
   }
 });
@@ -27,6 +27,16 @@ const HTML = `
     <div class="actions">
       <button id="btn-apply" disabled>Apply</button>
       <button id="btn-revert" disabled>Revert</button>
+      <select id="pin-strategy">
+        <option value="keep" selected>Keep current style</option>
+        <option value="caret">Caret</option>
+        <option value="exact">Exact</option>
+      </select>
+      <select id="release-mode">
+        <option value="" selected>Latest per branch</option>
+        <option value="all">All releases</option>
+        <option value="all" data-include="RC,beta,alpha,dev">All, including pre-releases and dev</option>
+      </select>
     </div>
     <table id="packages-table">
       <thead><tr><th>Package</th><th>Current</th><th>Drupal Core</th><th>Available</th></tr></thead>
@@ -75,6 +85,8 @@ let mockUpdateComposer;
 let mockBuildVersionMap;
 let mockBuildComposerCommands;
 let mockBuildDryRunCommand;
+let mockFindPatchedUpdates;
+let mockSummarizeUpdates;
 
 beforeEach(async () => {
   vi.resetModules();
@@ -88,14 +100,20 @@ beforeEach(async () => {
   mockBuildVersionMap = vi.fn().mockReturnValue({});
   mockBuildComposerCommands = vi.fn().mockReturnValue([]);
   mockBuildDryRunCommand = vi.fn().mockReturnValue("");
+  mockFindPatchedUpdates = vi.fn().mockReturnValue([]);
+  mockSummarizeUpdates = vi.fn().mockReturnValue("");
 
   vi.doMock("./api.js", () => ({
     parseComposer: mockParseComposer,
     fetchReleases: mockFetchReleases,
     updateComposer: mockUpdateComposer,
     buildVersionMap: mockBuildVersionMap,
+    splitReplacements: (selected) => ({ versions: selected, replacements: {} }),
+    REPLACE_PREFIX: "replace:",
     buildComposerCommands: mockBuildComposerCommands,
     buildDryRunCommand: mockBuildDryRunCommand,
+    findPatchedUpdates: mockFindPatchedUpdates,
+    summarizeUpdates: mockSummarizeUpdates,
   }));
 
   // Mock clipboard API
@@ -660,6 +678,42 @@ describe("Core packages", () => {
     expect($("#btn-apply").disabled).toBe(false);
   });
 
+  it("marks Drupal releases incompatible with the selected core version", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/core-recommended": "^10.4", "drupal/gin": "^3.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      core_packages: [{ name: "drupal/core-recommended", module: "core-recommended", version: "^10.4" }],
+      drupal_packages: [{ name: "drupal/gin", module: "gin", version: "^3.0" }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockImplementation((name, options) => {
+      if (name === "drupal/core-recommended") {
+        return Promise.resolve({ releases: [{ name: "drupal 11.1.0", version: "11.1.0", version_pin: "^11.1" }] });
+      }
+      return Promise.resolve({
+        releases: [{ name: "gin 3.0.5", version: "3.0.5", version_pin: "^3.0", core_compatibility: "^9 || ^10", core_incompatible: options.core === "11.1.0" }],
+      });
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const ginSelect = $("#select-drupal\\/gin");
+    expect(ginSelect.options[1].textContent).not.toContain("[incompatible with selected core]");
+
+    const select = $("#select-core");
+    select.value = "^11.1";
+    select.dispatchEvent(new Event("change"));
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenLastCalledWith("drupal/gin", expect.objectContaining({ core: "11.1.0" }));
+    expect(ginSelect.options[1].textContent).toContain("[incompatible with selected core]");
+  });
+
   it("shows section headers when core and other packages coexist", async () => {
     const textarea = $("#composer-textarea");
     textarea.value = JSON.stringify({ require: { "drupal/core-recommended": "^11", "drupal/gin": "^5.0" } });
@@ -767,6 +821,30 @@ describe("loadComposer flow", () => {
     expect(select.options[0].textContent).toContain("(current)");
   });
 
+  it("links sub-modules to the drupal.org project reported with their releases", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/admin_toolbar_tools": "^3.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [{ name: "drupal/admin_toolbar_tools", module: "admin_toolbar_tools", version: "^3.0" }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockResolvedValue({
+      project: "admin_toolbar",
+      releases: [{ name: "admin_toolbar 3.5.0", version: "3.5.0", version_pin: "^3.5" }],
+    });
+    mockBuildComposerCommands.mockReturnValue([]);
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const link = Array.from($$("#packages-body tr")).map(r => r.querySelector("a")).find(a => a);
+    expect(link.textContent).toBe("drupal/admin_toolbar_tools");
+    expect(link.href).toContain("drupal.org/project/admin_toolbar#project-releases");
+  });
+
   it("updates Drupal Core column when changing dropdown selection", async () => {
     const textarea = $("#composer-textarea");
     textarea.value = JSON.stringify({ require: { "drupal/gin": "^5.0" } });
@@ -834,6 +912,363 @@ describe("loadComposer flow", () => {
     expect(link.href).toContain("packagist.org/packages/drush/drush");
   });
 
+  it("marks require-dev packages with a dev tag", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ "require-dev": { "drush/drush": "^12" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "drush/drush", module: "drush/drush", version: "^12", section: "require-dev" }],
+    });
+    mockFetchReleases.mockResolvedValue({ releases: [] });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const tag = $("#packages-body .tag-dev");
+    expect(tag).toBeTruthy();
+    expect(tag.textContent).toBe("dev");
+  });
+
+  it("shows the installed version and passes it to fetchReleases", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/gin": "^5.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [{ name: "drupal/gin", module: "gin", version: "^5.0", installed: "5.0.3" }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [{ name: "gin 5.0.3", version: "5.0.3", version_pin: "^5.0", relation: "installed" }],
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenCalledWith("drupal/gin", { installed: "5.0.3", current: "^5.0", repositories: undefined, minimumStability: undefined, preferStable: undefined, php: undefined, pin: "keep" });
+    expect($("#packages-body .installed").textContent).toBe("installed: 5.0.3");
+    const select = $("#select-drupal\\/gin");
+    expect(select.options[1].textContent).toContain("[installed]");
+  });
+
+  it("passes custom repositories to fetchReleases", async () => {
+    const repositories = [{ type: "composer", url: "https://satis.example.com" }];
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/lib": "^1.0" }, repositories });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "acme/lib", module: "acme/lib", version: "^1.0" }],
+    });
+    mockFetchReleases.mockResolvedValue({ releases: [] });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenCalledWith("acme/lib", { installed: undefined, current: "^1.0", repositories, minimumStability: undefined, preferStable: undefined, php: undefined, pin: "keep" });
+  });
+
+  it("passes the stability settings to fetchReleases", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/lib": "^1.0" }, "minimum-stability": "RC", "prefer-stable": true });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "acme/lib", module: "acme/lib", version: "^1.0" }],
+    });
+    mockFetchReleases.mockResolvedValue({ releases: [] });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenCalledWith("acme/lib", { installed: undefined, current: "^1.0", repositories: undefined, minimumStability: "RC", preferStable: true, php: undefined, pin: "keep" });
+  });
+
+  it("passes the platform PHP version to fetchReleases and shows PHP requirements", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/lib": "^1.0" }, config: { platform: { php: "8.1.0" } } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "acme/lib", module: "acme/lib", version: "^1.0" }],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [{ name: "acme/lib 1.2.0", version: "1.2.0", version_pin: "^1.2", php: ">=8.1" }],
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenCalledWith("acme/lib", { installed: undefined, current: "^1.0", repositories: undefined, minimumStability: undefined, preferStable: undefined, php: "8.1.0", pin: "keep" });
+    const select = $("#select-acme\\/lib");
+    expect(select.options[1].textContent).toContain("[php >=8.1]");
+  });
+
+  it("re-fetches releases with the selected pin style", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/lib": "~1.0.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "acme/lib", module: "acme/lib", version: "~1.0.0" }],
+    });
+    mockFetchReleases.mockResolvedValue({ releases: [] });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+    expect(mockFetchReleases).toHaveBeenLastCalledWith("acme/lib", expect.objectContaining({ pin: "keep" }));
+
+    const pinSelect = $("#pin-strategy");
+    pinSelect.value = "exact";
+    pinSelect.dispatchEvent(new Event("change"));
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenLastCalledWith("acme/lib", expect.objectContaining({ pin: "exact" }));
+  });
+
+  it("re-fetches all releases when the release mode changes", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/lib": "^1.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "acme/lib", module: "acme/lib", version: "^1.0" }],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [
+        { name: "acme/lib 1.0.1", version: "1.0.1", version_pin: "^1.0.1", date: "2024-11-19T10:41:07Z" },
+        { name: "acme/lib 1.0.0", version: "1.0.0", version_pin: "^1.0.0" },
+      ],
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+    expect(mockFetchReleases).toHaveBeenLastCalledWith("acme/lib", expect.objectContaining({ mode: undefined, include: undefined }));
+
+    const modeSelect = $("#release-mode");
+    modeSelect.selectedIndex = 2;
+    modeSelect.dispatchEvent(new Event("change"));
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockFetchReleases).toHaveBeenLastCalledWith("acme/lib", expect.objectContaining({ mode: "all", include: "RC,beta,alpha,dev" }));
+    const select = $("#select-acme\\/lib");
+    expect(select.options[1].textContent).toContain("[2024-11-19]");
+    expect(select.options[2].textContent).not.toContain("[20");
+  });
+
+  it("shows the update type of releases and how far a package is behind", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/lib": "^1.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "acme/lib", module: "acme/lib", version: "^1.0" }],
+    });
+    const releases = [
+      { name: "acme/lib 2.0.0", version: "2.0.0", version_pin: "^2.0", update: "major" },
+      { name: "acme/lib 1.0.0", version: "1.0.0", version_pin: "^1.0", update: "none" },
+    ];
+    mockFetchReleases.mockResolvedValue({ releases });
+    mockSummarizeUpdates.mockReturnValue("1 major behind");
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    expect(mockSummarizeUpdates).toHaveBeenCalledWith(releases);
+    expect($(".update-summary").textContent).toBe("1 major behind");
+    const select = $("#select-acme\\/lib");
+    expect(select.options[1].textContent).toContain("[major update]");
+    expect(select.options[2].textContent).not.toContain("update]");
+  });
+
+  it("highlights packages below a security release", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/gin": "^4.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [{ name: "drupal/gin", module: "gin", version: "^4.0" }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [{ name: "gin 4.0.1", version: "4.0.1", version_pin: "^4.0", security: true, security_version: "4.0.1" }],
+      security_update: "4.0.1",
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const tag = $(".tag-security");
+    expect(tag).not.toBeNull();
+    expect(tag.title).toContain("4.0.1");
+    const select = $("#select-drupal\\/gin");
+    expect(select.options[1].textContent).toContain("[security]");
+  });
+
+  it("lists security advisories of packages and releases", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drush/drush": "^12.4" } });
+
+    const advisory = { id: "PKSA-1", package: "drush/drush", title: "Code execution", cve: "CVE-2024-12345", affected_versions: "<12.5.6" };
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [{ name: "drush/drush", module: "drush/drush", version: "^12.4" }],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [
+        { name: "drush/drush 12.5.6", version: "12.5.6", version_pin: "^12.5" },
+        { name: "drush/drush 12.5.5", version: "12.5.5", version_pin: "^12.5", advisories: [advisory] },
+      ],
+      advisories: [advisory],
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const tag = $(".tag-advisory");
+    expect(tag).not.toBeNull();
+    expect(tag.textContent).toBe("advisories (1)");
+    expect(tag.title).toContain("CVE-2024-12345: Code execution");
+    const select = $("#select-drush\\/drush");
+    expect(select.options[1].textContent).not.toContain("advisories]");
+    expect(select.options[2].textContent).toContain("[1 advisories]");
+  });
+
+  it("warns about unsupported projects and branches", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/old_module": "^1.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [{ name: "drupal/old_module", module: "old_module", version: "^1.0" }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [],
+      project_status: "unsupported",
+      project_warning: "project is no longer supported by its maintainers",
+      branch_unsupported: true,
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const tags = $$(".tag-project");
+    expect(tags.length).toBe(2);
+    expect(tags[0].textContent).toBe("unsupported");
+    expect(tags[0].title).toBe("project is no longer supported by its maintainers");
+    expect(tags[1].textContent).toBe("unsupported branch");
+    expect($("#packages-body").textContent).toContain("No releases: project is no longer supported by its maintainers");
+  });
+
+  it("tags abandoned packages and offers their replacement", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "acme/old": "^1.0", "acme/locked": "^2.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [],
+      composer_packages: [
+        { name: "acme/locked", module: "acme/locked", version: "^2.0", abandoned: true },
+        { name: "acme/old", module: "acme/old", version: "^1.0" },
+      ],
+    });
+    mockFetchReleases.mockImplementation(async (name) => ({
+      releases: [{ name: "", version: "1.1.0", version_pin: "^1.1" }],
+      ...(name === "acme/old" ? { abandoned: true, replacement: "acme/new" } : {}),
+    }));
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const tags = $$(".tag-project");
+    expect(tags.length).toBe(2);
+    expect(tags[0].title).toBe("The package is abandoned.");
+    expect(tags[1].title).toBe("The package is abandoned, use acme/new instead.");
+
+    expect([...$("#select-acme\\/locked").options].map(o => o.value)).toEqual(["^2.0", "^1.1"]);
+    const options = [...$("#select-acme\\/old").options];
+    expect(options[options.length - 1].value).toBe("replace:acme/new");
+    expect(options[options.length - 1].textContent).toBe("Replace with acme/new");
+  });
+
+  it("tags patched packages and warns when their version changes", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/gin": "^5.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [{
+        name: "drupal/gin", module: "gin", version: "^5.0",
+        patches: [{ description: "Fix toolbar", url: "gin.patch" }],
+      }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [{ name: "gin 6.0.0", version: "6.0.0", version_pin: "^6.0" }],
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const tag = $("#packages-body .tag-patched");
+    expect(tag.textContent).toBe("patched (1)");
+    expect(tag.title).toContain("Fix toolbar");
+
+    const warning = $("#packages-body .patch-warning");
+    expect(warning.hidden).toBe(true);
+
+    const select = $("#select-drupal\\/gin");
+    select.value = "^6.0";
+    select.dispatchEvent(new Event("change"));
+    expect(warning.hidden).toBe(false);
+  });
+
+  it("marks releases that are already allowed", async () => {
+    const textarea = $("#composer-textarea");
+    textarea.value = JSON.stringify({ require: { "drupal/gin": "^5.0" } });
+
+    mockParseComposer.mockResolvedValue({
+      drupal_packages: [{ name: "drupal/gin", module: "gin", version: "^5.0" }],
+      composer_packages: [],
+    });
+    mockFetchReleases.mockResolvedValue({
+      releases: [{ name: "gin 5.1.0", version: "5.1.0", version_pin: "^5.1", allowed: true }],
+    });
+
+    $("#btn-edit").click();
+    $("#btn-edit").click();
+    await flushPromises();
+    await flushPromises();
+
+    const select = $("#select-drupal\\/gin");
+    expect(select.options[1].textContent).toContain("[already allowed]");
+  });
+
   it("renders both Drupal and Composer packages with section headers", async () => {
     const textarea = $("#composer-textarea");
     textarea.value = JSON.stringify({ require: { "drupal/gin": "^5.0", "drush/drush": "^12" } });
@@ -870,7 +1305,7 @@ describe("loadComposer flow", () => {
     $("#btn-edit").click();
     await flushPromises();
 
-    expect(mockParseComposer).toHaveBeenCalledWith(composerJSON);
+    expect(mockParseComposer).toHaveBeenCalledWith(composerJSON, null);
   });
 
   it("calls fetchReleases with full package name", async () => {
@@ -958,8 +1393,8 @@ describe("Apply versions", () => {
     ]);
     mockBuildVersionMap.mockReturnValue({ "drupal/gin": "^6.0" });
 
-    const updatedJSON = { require: { "drupal/gin": "^6.0" } };
-    mockUpdateComposer.mockResolvedValue({ composer_json: updatedJSON });
+    const updatedText = '{\n  "require": {"drupal/gin": "^6.0"}\n}\n';
+    mockUpdateComposer.mockResolvedValue(updatedText);
 
     // Load first
     $("#btn-edit").click();
@@ -978,7 +1413,8 @@ describe("Apply versions", () => {
     await flushPromises();
     await flushPromises();
 
-    expect(mockUpdateComposer).toHaveBeenCalled();
+    expect(mockUpdateComposer).toHaveBeenCalledWith(JSON.stringify(composerJSON), { "drupal/gin": "^6.0" }, {});
+    expect(textarea.value).toBe(updatedText);
   });
 
   it("keeps Apply disabled when no changes are selected", async () => {
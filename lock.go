@@ -0,0 +1,122 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words encoding json strings
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ComposerLock represents the parts of a composer.lock file needed to find installed versions.
+type ComposerLock struct {
+	Packages    []LockedPackage `json:"packages"`     // packages locked from "require"
+	PackagesDev []LockedPackage `json:"packages-dev"` // packages locked from "require-dev"
+}
+
+// LockedPackage represents a single package entry in a composer.lock file.
+type LockedPackage struct {
+	Name      string     `json:"name"`    // composer package name
+	Version   string     `json:"version"` // exact installed version, e.g. "5.0.3" or "dev-main"
+	Source    lockSource `json:"source"`
+	Dist      lockSource `json:"dist"`
+	Reference string     `json:"-"` // source reference (e.g. a commit hash), falling back to the dist reference
+
+	Abandoned   bool   `json:"-"` // the package is marked as abandoned
+	Replacement string `json:"-"` // package suggested to replace the abandoned package, if any
+}
+
+// lockSource represents the "source" or "dist" entry of a locked package.
+type lockSource struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Reference string `json:"reference"`
+}
+
+// ParseComposerLock parses the contents of a composer.lock file.
+func ParseComposerLock(data []byte) (ComposerLock, error) {
+	var lock ComposerLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return ComposerLock{}, fmt.Errorf("parse composer.lock: %w", err)
+	}
+	return lock, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for LockedPackage.
+func (p *LockedPackage) UnmarshalJSON(data []byte) error {
+	type lockedPackage LockedPackage // prevent recursion
+	var raw struct {
+		lockedPackage
+		Abandoned json.RawMessage `json:"abandoned"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal locked package: %w", err)
+	}
+	*p = LockedPackage(raw.lockedPackage)
+	p.Reference = p.Source.Reference
+	if p.Reference == "" {
+		p.Reference = p.Dist.Reference
+	}
+	p.Abandoned, p.Replacement = parseAbandoned(raw.Abandoned)
+	return nil
+}
+
+// Lookup returns the locked entry for the given package name.
+func (l ComposerLock) Lookup(name string) (LockedPackage, bool) {
+	for _, locked := range [][]LockedPackage{l.Packages, l.PackagesDev} {
+		for _, pkg := range locked {
+			if pkg.Name == name {
+				return pkg, true
+			}
+		}
+	}
+	return LockedPackage{}, false
+}
+
+// Annotate sets the installed version, reference and abandonment of each package found in the lock file.
+// Packages that are not locked are left unchanged.
+func (l ComposerLock) Annotate(pkgs []Package) {
+	for i := range pkgs {
+		locked, ok := l.Lookup(pkgs[i].Name)
+		if !ok {
+			continue
+		}
+		pkgs[i].Installed = locked.Version
+		pkgs[i].Reference = locked.Reference
+		pkgs[i].Abandoned = locked.Abandoned
+		pkgs[i].Replacement = locked.Replacement
+	}
+}
+
+// =============================================================================
+// Installed Version Relation
+// =============================================================================
+
+// ReleaseRelation describes how a release relates to the installed version of a package.
+type ReleaseRelation string
+
+const (
+	RelationInstalled ReleaseRelation = "installed" // the release is the installed version
+	RelationNewer     ReleaseRelation = "newer"     // the release is newer than the installed version
+	RelationOlder     ReleaseRelation = "older"     // the release is older than the installed version
+)
+
+// MarkInstalled sets the Relation of each release relative to the installed version.
+// It does nothing if installed is empty or not a numbered version (e.g. "dev-main").
+func MarkInstalled(releases []Release, installed string) {
+	current := ParseVersion(strings.TrimPrefix(installed, "v"))
+	if current.Major < 0 {
+		return
+	}
+	for i := range releases {
+		cmp := ParseVersion(releases[i].Version).compareRelease(current) // composer drops the "8.x-" prefix
+		switch {
+		case cmp > 0:
+			releases[i].Relation = RelationNewer
+		case cmp < 0:
+			releases[i].Relation = RelationOlder
+		default:
+			releases[i].Relation = RelationInstalled
+		}
+	}
+}
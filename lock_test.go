@@ -0,0 +1,152 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words testing github composer drupal update drupalupdate
+import (
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+// sampleLockJSON is a minimal composer.lock for testing.
+const sampleLockJSON = `{
+	"content-hash": "abc123",
+	"packages": [
+		{
+			"name": "drupal/admin_toolbar",
+			"version": "3.5.0",
+			"source": {"type": "git", "url": "https://git.drupalcode.org/project/admin_toolbar.git", "reference": "3.5.0"},
+			"dist": {"type": "zip", "url": "https://ftp.drupal.org/files/projects/admin_toolbar-3.5.0.zip", "reference": "3.5.0"}
+		},
+		{
+			"name": "drush/drush",
+			"version": "12.5.6",
+			"dist": {"type": "zip", "url": "https://api.github.com/repos/drush-ops/drush/zipball/1a2b3c", "reference": "1a2b3c"}
+		},
+		{
+			"name": "acme/old",
+			"version": "1.1.0",
+			"abandoned": "acme/new"
+		}
+	],
+	"packages-dev": [
+		{
+			"name": "phpunit/phpunit",
+			"version": "dev-main",
+			"source": {"type": "git", "url": "https://github.com/sebastianbergmann/phpunit.git", "reference": "ffeeddcc"}
+		}
+	]
+}`
+
+// =============================================================================
+// ParseComposerLock
+// =============================================================================
+
+func TestParseComposerLock(t *testing.T) {
+	t.Parallel()
+	lock, err := drupalupdate.ParseComposerLock([]byte(sampleLockJSON))
+	if err != nil {
+		t.Fatalf("ParseComposerLock returned error: %v", err)
+	}
+
+	if len(lock.Packages) != 3 || len(lock.PackagesDev) != 1 {
+		t.Fatalf("expected 3 packages and 1 dev package, got %d and %d", len(lock.Packages), len(lock.PackagesDev))
+	}
+
+	drush, ok := lock.Lookup("drush/drush")
+	if !ok {
+		t.Fatal("expected drush/drush to be locked")
+	}
+	if drush.Version != "12.5.6" {
+		t.Errorf("expected 12.5.6, got %s", drush.Version)
+	}
+	// no source reference, falls back to the dist reference
+	if drush.Reference != "1a2b3c" {
+		t.Errorf("expected reference 1a2b3c, got %s", drush.Reference)
+	}
+
+	phpunit, ok := lock.Lookup("phpunit/phpunit")
+	if !ok || phpunit.Reference != "ffeeddcc" || phpunit.Abandoned {
+		t.Errorf("unexpected dev package: %+v", phpunit)
+	}
+
+	old, ok := lock.Lookup("acme/old")
+	if !ok || !old.Abandoned || old.Replacement != "acme/new" {
+		t.Errorf("expected acme/old to be abandoned for acme/new, got %+v", old)
+	}
+
+	if _, ok := lock.Lookup("drupal/gin"); ok {
+		t.Error("expected drupal/gin not to be locked")
+	}
+}
+
+func TestParseComposerLock_Invalid(t *testing.T) {
+	t.Parallel()
+	_, err := drupalupdate.ParseComposerLock([]byte(`not json`))
+	if err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestComposerLock_Annotate(t *testing.T) {
+	t.Parallel()
+	lock, err := drupalupdate.ParseComposerLock([]byte(sampleLockJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs := []drupalupdate.Package{
+		{Name: "drupal/admin_toolbar", Module: "admin_toolbar", Version: "^3.5"},
+		{Name: "drupal/gin", Module: "gin", Version: "^5.0"},
+		{Name: "acme/old", Module: "acme/old", Version: "^1.0"},
+	}
+	lock.Annotate(pkgs)
+
+	if pkgs[0].Installed != "3.5.0" || pkgs[0].Reference != "3.5.0" {
+		t.Errorf("unexpected first package: %+v", pkgs[0])
+	}
+	if pkgs[1].Installed != "" || pkgs[1].Reference != "" {
+		t.Errorf("unexpected second package: %+v", pkgs[1])
+	}
+	if !pkgs[2].Abandoned || pkgs[2].Replacement != "acme/new" {
+		t.Errorf("unexpected third package: %+v", pkgs[2])
+	}
+}
+
+// =============================================================================
+// MarkInstalled
+// =============================================================================
+
+func TestMarkInstalled(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{
+		{Version: "4.0.2"},
+		{Version: "8.x-3.5"},
+		{Version: "3.0.5"},
+	}
+
+	drupalupdate.MarkInstalled(releases, "v3.5.0")
+
+	want := []drupalupdate.ReleaseRelation{
+		drupalupdate.RelationNewer,
+		drupalupdate.RelationInstalled,
+		drupalupdate.RelationOlder,
+	}
+	for i, relation := range want {
+		if releases[i].Relation != relation {
+			t.Errorf("release %s: expected %q, got %q", releases[i].Version, relation, releases[i].Relation)
+		}
+	}
+}
+
+func TestMarkInstalled_Unknown(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{{Version: "4.0.2"}}
+
+	drupalupdate.MarkInstalled(releases, "")
+	drupalupdate.MarkInstalled(releases, "dev-main")
+
+	if releases[0].Relation != "" {
+		t.Errorf("expected no relation, got %q", releases[0].Relation)
+	}
+}
@@ -0,0 +1,91 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words errors slices strings
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ReleaseMode determines which releases of a package are fetched.
+type ReleaseMode string
+
+const (
+	ModeLatest ReleaseMode = "latest" // the latest release per supported branch or major version, the default
+	ModeAll    ReleaseMode = "all"    // every published release
+)
+
+var (
+	errReleaseModeInvalid = errors.New("invalid release mode")
+	errStabilityInvalid   = errors.New("invalid stability")
+)
+
+// ParseReleaseMode parses the name of a release mode.
+// An empty name results in [ModeLatest].
+func ParseReleaseMode(s string) (ReleaseMode, error) {
+	if s == "" {
+		return ModeLatest, nil
+	}
+	switch mode := ReleaseMode(strings.ToLower(s)); mode {
+	case ModeLatest, ModeAll:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q", errReleaseModeInvalid, s)
+	}
+}
+
+// ParseStabilities parses a comma-separated list of stabilities, e.g. "beta,dev".
+// Names are normalized to the Stability constants, where "stable" is [StabilityStable].
+func ParseStabilities(s string) ([]string, error) {
+	var stabilities []string
+	for name := range strings.SplitSeq(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		stability := normalizeStability(name)
+		if stability == StabilityStable && !strings.EqualFold(name, "stable") {
+			return nil, fmt.Errorf("%w: %q", errStabilityInvalid, name)
+		}
+		if !slices.Contains(stabilities, stability) {
+			stabilities = append(stabilities, stability)
+		}
+	}
+	return stabilities, nil
+}
+
+// stabilityName returns the name of a stability for display, e.g. "stable" for [StabilityStable].
+func stabilityName(stability string) string {
+	if stability == StabilityStable {
+		return "stable"
+	}
+	return stability
+}
+
+// releaseFilter decides which versions of a package are offered.
+type releaseFilter struct {
+	stability StabilitySettings // stability settings deciding which releases are offered
+	include   []string          // additional stabilities offered in [ModeAll]
+	php       string            // PHP version of the platform, if any
+	all       bool              // offer all allowed releases instead of the latest per branch or major version
+}
+
+// releaseFilter returns the filter for releases fetched by c.
+func (c *Client) releaseFilter() releaseFilter {
+	return releaseFilter{stability: c.Stability, include: c.Include, php: c.PlatformPHP, all: c.Mode == ModeAll}
+}
+
+// allows reports whether releases with the given stability are offered in [ModeAll].
+func (f releaseFilter) allows(stability string) bool {
+	return f.stability.allows(stability) || slices.Contains(f.include, stability)
+}
+
+// selectPackagist returns the releases of pkg offered from versions ordered newest first.
+func (f releaseFilter) selectPackagist(pkg string, versions []packagistVersion) []Release {
+	if f.all {
+		return allPackagistReleases(pkg, versions, f)
+	}
+	return latestPerPackagistMajor(pkg, versions, f.stability, f.php)
+}
@@ -0,0 +1,214 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest slices testing time github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestParseReleaseMode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    drupalupdate.ReleaseMode
+		wantErr bool
+	}{
+		{"", drupalupdate.ModeLatest, false},
+		{"latest", drupalupdate.ModeLatest, false},
+		{"All", drupalupdate.ModeAll, false},
+		{"some", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := drupalupdate.ParseReleaseMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReleaseMode(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReleaseMode(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStabilities(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"beta,dev", []string{drupalupdate.StabilityBeta, drupalupdate.StabilityDev}, false},
+		{" rc , Alpha,rc", []string{drupalupdate.StabilityRC, drupalupdate.StabilityAlpha}, false},
+		{"stable", []string{drupalupdate.StabilityStable}, false},
+		{"beta,nightly", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := drupalupdate.ParseStabilities(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStabilities(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseStabilities(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// sampleAllXML is a drupal.org release history response with pre-releases and a development branch.
+const sampleAllXML = `<?xml version="1.0" encoding="utf-8"?>
+<project xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <title>Admin Toolbar</title>
+  <short_name>admin_toolbar</short_name>
+  <project_status>published</project_status>
+  <supported_branches>3.0.,4.0.</supported_branches>
+  <releases>
+    <release>
+      <name>admin_toolbar 4.0.x-dev</name>
+      <version>4.0.x-dev</version>
+      <status>published</status>
+      <date>1732000000</date>
+    </release>
+    <release>
+      <name>admin_toolbar 4.0.2</name>
+      <version>4.0.2</version>
+      <status>published</status>
+      <date>1731000000</date>
+    </release>
+    <release>
+      <name>admin_toolbar 4.0.1</name>
+      <version>4.0.1</version>
+      <status>published</status>
+      <date>1730000000</date>
+      <terms>
+        <term><name>Release type</name><value>Security update</value></term>
+      </terms>
+    </release>
+    <release>
+      <name>admin_toolbar 4.0.0-beta1</name>
+      <version>4.0.0-beta1</version>
+      <status>published</status>
+    </release>
+    <release>
+      <name>admin_toolbar 4.0.0</name>
+      <version>4.0.0</version>
+      <status>unpublished</status>
+    </release>
+    <release>
+      <name>admin_toolbar 3.0.5</name>
+      <version>3.0.5</version>
+      <status>published</status>
+    </release>
+  </releases>
+</project>`
+
+func TestFetchReleases_ModeAll_Drupal(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin_toolbar/current" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(sampleAllXML)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = server.URL
+
+	tests := []struct {
+		name    string
+		include []string
+		want    []string
+	}{
+		{"stable", nil, []string{"4.0.2", "4.0.1", "3.0.5"}},
+		{"include beta", []string{drupalupdate.StabilityBeta}, []string{"4.0.2", "4.0.1", "4.0.0-beta1", "3.0.5"}},
+		{"include dev", []string{drupalupdate.StabilityDev}, []string{"4.0.x-dev", "4.0.2", "4.0.1", "3.0.5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			releases, err := client.WithMode(drupalupdate.ModeAll, tt.include...).FetchReleases(t.Context(), "drupal/admin_toolbar")
+			if err != nil {
+				t.Fatalf("FetchReleases returned error: %v", err)
+			}
+			var versions []string
+			for _, release := range releases {
+				versions = append(versions, release.Version)
+			}
+			if !slices.Equal(versions, tt.want) {
+				t.Errorf("versions = %v, want %v", versions, tt.want)
+			}
+		})
+	}
+
+	releases, err := client.WithMode(drupalupdate.ModeAll, drupalupdate.StabilityBeta).FetchReleases(t.Context(), "drupal/admin_toolbar")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	got := releases[0]
+	if got.Stability != "stable" || got.Branch != "4.0.x" || !got.Date.Equal(time.Unix(1731000000, 0)) {
+		t.Errorf("expected stable 4.0.x release with date, got %q, %q, %v", got.Stability, got.Branch, got.Date)
+	}
+	if got.VersionPin != "^4.0.2" || got.SecurityVersion != "4.0.1" {
+		t.Errorf("expected pin ^4.0.2 and security version 4.0.1, got %q and %q", got.VersionPin, got.SecurityVersion)
+	}
+	if beta := releases[2]; beta.Stability != drupalupdate.StabilityBeta || beta.SecurityVersion != "" || !beta.Date.IsZero() {
+		t.Errorf("expected beta release without security version and date, got %+v", beta)
+	}
+}
+
+func TestFetchReleases_ModeAll_Packagist(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/p2/drush/drush.json" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(`{"packages": {"drush/drush": [
+			{"version": "13.0.1", "version_normalized": "13.0.1.0", "time": "2024-11-19T10:41:07+00:00"},
+			{"version": "13.0.0-rc1", "version_normalized": "13.0.0.0-RC1"},
+			{"version": "12.5.6", "version_normalized": "12.5.6.0"},
+			{"version": "dev-main", "version_normalized": "dev-main"}
+		]}}`)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.PackagistBaseURL = server.URL
+
+	releases, err := client.WithMode(drupalupdate.ModeAll, drupalupdate.StabilityRC, drupalupdate.StabilityDev).FetchReleases(t.Context(), "drush/drush")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	var versions []string
+	for _, release := range releases {
+		versions = append(versions, release.Version)
+	}
+	if want := []string{"13.0.1", "13.0.0-rc1", "12.5.6", "dev-main"}; !slices.Equal(versions, want) {
+		t.Fatalf("versions = %v, want %v", versions, want)
+	}
+	if want := time.Date(2024, 11, 19, 10, 41, 7, 0, time.UTC); !releases[0].Date.Equal(want) {
+		t.Errorf("expected date %v, got %v", want, releases[0].Date)
+	}
+	if releases[1].Stability != drupalupdate.StabilityRC || releases[1].Branch != "13.0.x" {
+		t.Errorf("expected RC release on branch 13.0.x, got %q on %q", releases[1].Stability, releases[1].Branch)
+	}
+	if releases[3].Stability != drupalupdate.StabilityDev || releases[3].Branch != "main" || releases[3].VersionPin != "dev-main" {
+		t.Errorf("expected dev-main branch, got %+v", releases[3])
+	}
+}
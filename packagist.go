@@ -3,49 +3,206 @@ package drupalupdate
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// FetchPackagistReleases fetches the latest stable release per major version
-// from the Packagist p2 API.
+// FetchPackagistReleases fetches the latest release per major version, or all releases in [ModeAll],
+// from the Packagist p2 API, honoring the stability settings and platform PHP version of c.
 func (c *Client) FetchPackagistReleases(ctx context.Context, pkg string) (releases []Release, err error) {
-	return fetchResponse(ctx, c, fmt.Sprintf("%s/p2/%s.json", c.PackagistBaseURL, pkg), func(body io.Reader) ([]Release, error) {
+	found, err := c.fetchPackagistPackage(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	return found.Releases, nil
+}
+
+// fetchPackagistPackage fetches the releases of pkg from the Packagist p2 API like [Client.FetchPackagistReleases],
+// including whether it is abandoned.
+func (c *Client) fetchPackagistPackage(ctx context.Context, pkg string) (PackageReleases, error) {
+	return c.fetchComposerMetadata(ctx, c.PackagistBaseURL+"/p2/%package%.json", pkg)
+}
+
+// fetchComposerMetadata fetches the latest release per major version
+// from composer p2 metadata files, as served by Packagist or Satis.
+// The template is the URL of the metadata files, where "%package%" is replaced by the package name.
+//
+// If development versions may be offered, the versions of development branches
+// are fetched from the "~dev" metadata file of pkg as well, if it exists.
+// The package is abandoned if its newest version is marked as such.
+func (c *Client) fetchComposerMetadata(ctx context.Context, template string, pkg string) (PackageReleases, error) {
+	filter := c.releaseFilter()
+
+	versions, err := c.fetchPackagistVersions(ctx, strings.ReplaceAll(template, "%package%", pkg), pkg)
+	if err != nil {
+		return PackageReleases{}, err
+	}
+	var result PackageReleases
+	if len(versions) > 0 {
+		result.Abandoned, result.Replacement = parseAbandoned(versions[0].Abandoned)
+	}
+	if filter.allows(StabilityDev) {
+		dev, err := c.fetchPackagistVersions(ctx, strings.ReplaceAll(template, "%package%", pkg+"~dev"), pkg)
+		if err != nil && !errors.Is(err, errHTTPNotFound) {
+			return PackageReleases{}, fmt.Errorf("development versions: %w", err)
+		}
+		versions = append(dev, versions...)
+	}
+
+	result.Releases = filter.selectPackagist(pkg, versions)
+	sortReleases(result.Releases)
+	return result, nil
+}
+
+// fetchPackagistVersions fetches the versions of pkg from a single p2 metadata file,
+// expanding them if the file is minified.
+func (c *Client) fetchPackagistVersions(ctx context.Context, url string, pkg string) ([]packagistVersion, error) {
+	return fetchResponse(ctx, c, url, func(body io.Reader) ([]packagistVersion, error) {
 		var result struct {
-			Packages map[string][]packagistVersion `json:"packages"`
+			Minified string                                  `json:"minified"`
+			Packages map[string][]map[string]json.RawMessage `json:"packages"`
 		}
 		if err := json.NewDecoder(body).Decode(&result); err != nil {
 			return nil, fmt.Errorf("decode JSON: %w", err)
 		}
 
-		versions := result.Packages[pkg]
-		releases = latestStablePerPackagistMajor(pkg, versions)
-		sortReleases(releases)
-		return releases, nil
+		entries := result.Packages[pkg]
+		if result.Minified != "" {
+			entries = expandMinifiedVersions(entries)
+		}
+		versions, err := decodePackagistVersions(entries)
+		if err != nil {
+			return nil, fmt.Errorf("decode JSON: %w", err)
+		}
+		return versions, nil
 	})
 }
 
+// =============================================================================
+// Minified Metadata
+// =============================================================================
+
+// minifiedUnset marks a field of a minified version that is not inherited from the previous version.
+const minifiedUnset = "__unset"
+
+// expandMinifiedVersions expands minified p2 metadata into full metadata per version, like composer does.
+// In minified metadata, each version only lists the fields that differ from the previous version,
+// and fields that the previous version has but it does not are set to "__unset".
+func expandMinifiedVersions(entries []map[string]json.RawMessage) []map[string]json.RawMessage {
+	expanded := make([]map[string]json.RawMessage, len(entries))
+	var previous map[string]json.RawMessage
+	for i, entry := range entries {
+		current := make(map[string]json.RawMessage, len(previous)+len(entry))
+		maps.Copy(current, previous)
+		for key, value := range entry {
+			if isMinifiedUnset(value) {
+				delete(current, key)
+				continue
+			}
+			current[key] = value
+		}
+		expanded[i] = current
+		previous = current
+	}
+	return expanded
+}
+
+// isMinifiedUnset reports whether value is the [minifiedUnset] marker.
+func isMinifiedUnset(value json.RawMessage) bool {
+	var s string
+	return json.Unmarshal(value, &s) == nil && s == minifiedUnset
+}
+
+// decodePackagistVersions decodes the fields of each version into a packagistVersion.
+func decodePackagistVersions(entries []map[string]json.RawMessage) ([]packagistVersion, error) {
+	versions := make([]packagistVersion, len(entries))
+	for i, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("encode version: %w", err)
+		}
+		if err := json.Unmarshal(data, &versions[i]); err != nil {
+			return nil, fmt.Errorf("version %d: %w", i, err)
+		}
+	}
+	return versions, nil
+}
+
 // =============================================================================
 // Packagist Version Filtering
 // =============================================================================
 
 // packagistVersion represents a single version entry from the Packagist API.
 type packagistVersion struct {
-	Version           string `json:"version"`
-	VersionNormalized string `json:"version_normalized"`
+	Version           string            `json:"version"`
+	VersionNormalized string            `json:"version_normalized"`
+	Require           map[string]string `json:"require"`
+	Time              string            `json:"time"`      // release date, e.g. "2024-11-19T10:41:07+00:00"
+	Abandoned         json.RawMessage   `json:"abandoned"` // true, or the name of a replacement package, see [parseAbandoned]
+	Source            struct {
+		URL string `json:"url"`
+	} `json:"source"`
+	Extra struct {
+		Drupal struct {
+			Version   string `json:"version"`   // drupal.org version, e.g. "8.x-1.0-rc17" for "1.0.0-rc17"
+			Datestamp string `json:"datestamp"` // release date as a unix timestamp
+		} `json:"drupal"`
+	} `json:"extra"` // set by the drupal.org composer repository
+}
+
+// parseAbandoned decodes the "abandoned" field of composer metadata,
+// which is either a boolean, or the name of a package replacing the abandoned one.
+func parseAbandoned(raw json.RawMessage) (abandoned bool, replacement string) {
+	if err := json.Unmarshal(raw, &abandoned); err == nil {
+		return abandoned, ""
+	}
+	if err := json.Unmarshal(raw, &replacement); err == nil {
+		return true, replacement
+	}
+	return false, ""
+}
+
+// release returns the Release of pkg for v.
+// Versions from the drupal.org composer repository use the drupal.org version, e.g. "8.x-1.0-rc17".
+func (v packagistVersion) release(pkg string) Release {
+	version := strings.TrimPrefix(v.Version, "v")
+	if v.Extra.Drupal.Version != "" {
+		version = v.Extra.Drupal.Version
+	}
+	parsed := ParseVersion(version)
+	release := Release{
+		Name:              pkg + " " + version,
+		Version:           version,
+		VersionPin:        parsed.VersionPin(),
+		CoreCompatibility: v.Require["drupal/core"],
+		PHP:               v.Require["php"],
+		Require:           v.Require,
+		Stability:         stabilityName(v.stability()),
+		Branch:            parsed.ReleaseBranch(),
+		SourceURL:         v.Source.URL,
+	}
+	if date, err := time.Parse(time.RFC3339, v.Time); err == nil {
+		release.Date = date
+	} else if stamp, err := strconv.ParseInt(v.Extra.Drupal.Datestamp, 10, 64); err == nil && stamp > 0 {
+		release.Date = time.Unix(stamp, 0).UTC()
+	}
+	return release
 }
 
 // isStable returns true if the Packagist version is a stable release
 // (not dev, alpha, beta, or RC).
 func (v packagistVersion) isStable() bool {
-	lower := strings.ToLower(v.Version)
-	for _, tag := range []string{"dev", "alpha", "beta", "rc"} {
-		if strings.Contains(lower, tag) {
-			return false
-		}
-	}
-	return true
+	return v.stability() == StabilityStable
+}
+
+// stability returns the stability of the Packagist version.
+func (v packagistVersion) stability() string {
+	return versionStability(v.Version)
 }
 
 // packagistMajorVersion extracts the major version number from a packagist version
@@ -58,26 +215,48 @@ func (v packagistVersion) majorVersion() string {
 	return ""
 }
 
-// latestStablePerPackagistMajor filters Packagist versions to the latest stable release
-// per major version. Versions are assumed to be ordered newest-first.
-func latestStablePerPackagistMajor(pkg string, versions []packagistVersion) []Release {
-	seen := make(map[string]bool)
-	var result []Release
+// latestPerPackagistMajor filters Packagist versions to the latest release per major version
+// that is stable enough for settings and can be installed on the given PHP version, if any.
+// Versions are assumed to be ordered newest-first.
+// Development branches (e.g. "dev-main") have no major version and are never offered.
+func latestPerPackagistMajor(pkg string, versions []packagistVersion, settings StabilitySettings, php string) []Release {
+	var majors []string
+	candidates := make(map[string][]packagistVersion)
 	for _, v := range versions {
-		if !v.isStable() {
+		if strings.HasPrefix(v.Version, "dev-") || !phpAllows(php, v.Require["php"]) {
 			continue
 		}
 		major := v.majorVersion()
-		if seen[major] {
+		if _, seen := candidates[major]; !seen {
+			majors = append(majors, major)
+		}
+		candidates[major] = append(candidates[major], v)
+	}
+
+	var result []Release
+	for _, major := range majors {
+		stabilities := make([]string, len(candidates[major]))
+		for i, v := range candidates[major] {
+			stabilities[i] = v.stability()
+		}
+		i := settings.pickRelease(stabilities)
+		if i < 0 {
+			continue
+		}
+		result = append(result, candidates[major][i].release(pkg))
+	}
+	return result
+}
+
+// allPackagistReleases returns all Packagist versions allowed by filter, ordered like versions.
+// Development branches (e.g. "dev-main") are only offered if the filter allows development versions.
+func allPackagistReleases(pkg string, versions []packagistVersion, filter releaseFilter) []Release {
+	var result []Release
+	for _, v := range versions {
+		if !filter.allows(v.stability()) || !phpAllows(filter.php, v.Require["php"]) {
 			continue
 		}
-		seen[major] = true
-		version := strings.TrimPrefix(v.Version, "v")
-		result = append(result, Release{
-			Name:       pkg + " " + version,
-			Version:    version,
-			VersionPin: ParseVersion(version).VersionPin(),
-		})
+		result = append(result, v.release(pkg))
 	}
 	return result
 }
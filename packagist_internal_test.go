@@ -1,6 +1,8 @@
 package drupalupdate
 
 import (
+	"encoding/json"
+	"maps"
 	"testing"
 )
 
@@ -64,7 +66,7 @@ func TestLatestStablePerMajor(t *testing.T) {
 		{Version: "11.0.0", VersionNormalized: "11.0.0.0"},
 	}
 
-	got := latestStablePerPackagistMajor("drush/drush", versions)
+	got := latestPerPackagistMajor("drush/drush", versions, StabilitySettings{}, "")
 
 	if len(got) != 3 {
 		t.Fatalf("expected 3 releases, got %d: %+v", len(got), got)
@@ -101,7 +103,7 @@ func TestLatestStablePerMajor_StripsVPrefix(t *testing.T) {
 		{Version: "v1.5.0", VersionNormalized: "1.5.0.0"},
 	}
 
-	got := latestStablePerPackagistMajor("some/pkg", versions)
+	got := latestPerPackagistMajor("some/pkg", versions, StabilitySettings{}, "")
 
 	if len(got) != 2 {
 		t.Fatalf("expected 2 releases, got %d", len(got))
@@ -121,9 +123,65 @@ func TestLatestStablePerMajor_AllUnstable(t *testing.T) {
 		{Version: "1.0.0-alpha1", VersionNormalized: "1.0.0.0-alpha1"},
 	}
 
-	got := latestStablePerPackagistMajor("pkg/x", versions)
+	got := latestPerPackagistMajor("pkg/x", versions, StabilitySettings{}, "")
 
 	if len(got) != 0 {
 		t.Fatalf("expected 0 releases, got %d", len(got))
 	}
 }
+
+func TestLatestPerMajor_Stability(t *testing.T) {
+	t.Parallel()
+	versions := []packagistVersion{
+		{Version: "dev-main", VersionNormalized: "9999999-dev"},
+		{Version: "3.0.0-RC1", VersionNormalized: "3.0.0.0-RC1"},
+		{Version: "2.1.0-beta1", VersionNormalized: "2.1.0.0-beta1"},
+		{Version: "2.0.0", VersionNormalized: "2.0.0.0"},
+	}
+
+	got := latestPerPackagistMajor("pkg/x", versions, StabilitySettings{MinimumStability: StabilityBeta}, "")
+	if len(got) != 2 || got[0].Version != "3.0.0-RC1" || got[1].Version != "2.1.0-beta1" {
+		t.Errorf("minimum-stability beta: unexpected releases %+v", got)
+	}
+	if got[0].VersionPin != "^3.0@RC" {
+		t.Errorf("expected version pin '^3.0@RC', got %s", got[0].VersionPin)
+	}
+
+	got = latestPerPackagistMajor("pkg/x", versions, StabilitySettings{MinimumStability: StabilityBeta, PreferStable: true}, "")
+	if len(got) != 2 || got[0].Version != "3.0.0-RC1" || got[1].Version != "2.0.0" {
+		t.Errorf("prefer-stable: unexpected releases %+v", got)
+	}
+}
+
+// =============================================================================
+// Minified Metadata
+// =============================================================================
+
+func TestExpandMinifiedVersions(t *testing.T) {
+	t.Parallel()
+	entries := []map[string]json.RawMessage{
+		{"version": json.RawMessage(`"2.0.0"`), "require": json.RawMessage(`{"php": ">=8.1"}`), "license": json.RawMessage(`["MIT"]`)},
+		{"version": json.RawMessage(`"1.1.0"`), "license": json.RawMessage(`"__unset"`)},
+		{"version": json.RawMessage(`"1.0.0"`), "require": json.RawMessage(`{"php": ">=7.4"}`)},
+	}
+
+	expanded := expandMinifiedVersions(entries)
+
+	want := []map[string]string{
+		{"version": `"2.0.0"`, "require": `{"php": ">=8.1"}`, "license": `["MIT"]`},
+		{"version": `"1.1.0"`, "require": `{"php": ">=8.1"}`},
+		{"version": `"1.0.0"`, "require": `{"php": ">=7.4"}`},
+	}
+	for i, fields := range want {
+		got := make(map[string]string, len(expanded[i]))
+		for key, value := range expanded[i] {
+			got[key] = string(value)
+		}
+		if !maps.Equal(got, fields) {
+			t.Errorf("version %d = %v, want %v", i, got, fields)
+		}
+	}
+	if _, ok := entries[1]["require"]; ok {
+		t.Error("expected the minified entries to be left unchanged")
+	}
+}
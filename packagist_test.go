@@ -3,6 +3,7 @@ package drupalupdate_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
@@ -99,3 +100,138 @@ func TestFetchPackagistReleases_InvalidJSON(t *testing.T) {
 		t.Fatal("expected error for invalid JSON")
 	}
 }
+
+// sampleMinifiedJSON is a minified Packagist p2 response, where each version only lists changed fields.
+const sampleMinifiedJSON = `{
+	"minified": "composer/2.0",
+	"packages": {
+		"drush/drush": [
+			{"version": "13.0.1", "version_normalized": "13.0.1.0", "time": "2024-11-19T10:41:07+00:00", "require": {"php": ">=8.2"}},
+			{"version": "13.0.0", "version_normalized": "13.0.0.0", "time": "2024-10-01T08:00:00+00:00"},
+			{"version": "12.5.6", "version_normalized": "12.5.6.0", "time": "2024-08-01T08:00:00+00:00", "require": "__unset"}
+		]
+	}
+}`
+
+// sampleDevJSON is the Packagist p2 response with the development branches of a package.
+const sampleDevJSON = `{
+	"minified": "composer/2.0",
+	"packages": {
+		"drush/drush": [
+			{"version": "dev-main", "version_normalized": "dev-main", "require": {"php": ">=8.3"}},
+			{"version": "12.x-dev", "version_normalized": "12.9999999.9999999.9999999-dev", "require": {"php": ">=8.1"}}
+		]
+	}
+}`
+
+// newMinifiedServer creates a mock Packagist server serving minified metadata and development branches.
+func newMinifiedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		switch r.URL.Path {
+		case "/p2/drush/drush.json":
+			body = sampleMinifiedJSON
+		case "/p2/drush/drush~dev.json":
+			body = sampleDevJSON
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchPackagistReleases_Minified(t *testing.T) {
+	t.Parallel()
+	server := newMinifiedServer(t)
+
+	client := drupalupdate.NewClient().WithMode(drupalupdate.ModeAll)
+	client.PackagistBaseURL = server.URL
+
+	releases, err := client.FetchPackagistReleases(t.Context(), "drush/drush")
+	if err != nil {
+		t.Fatalf("FetchPackagistReleases returned error: %v", err)
+	}
+
+	want := []struct{ version, php, date string }{
+		{"13.0.1", ">=8.2", "2024-11-19"},
+		{"13.0.0", ">=8.2", "2024-10-01"}, // inherited from 13.0.1
+		{"12.5.6", "", "2024-08-01"},      // unset
+	}
+	if len(releases) != len(want) {
+		t.Fatalf("expected %d releases, got %+v", len(want), releases)
+	}
+	for i, w := range want {
+		r := releases[i]
+		if r.Version != w.version || r.PHP != w.php || r.Date.Format("2006-01-02") != w.date {
+			t.Errorf("release %d = %s (php %q, %s), want %s (php %q, %s)", i, r.Version, r.PHP, r.Date.Format("2006-01-02"), w.version, w.php, w.date)
+		}
+	}
+}
+
+func TestFetchPackagistReleases_DevBranches(t *testing.T) {
+	t.Parallel()
+	server := newMinifiedServer(t)
+
+	client := drupalupdate.NewClient().WithMode(drupalupdate.ModeAll, drupalupdate.StabilityDev)
+	client.PackagistBaseURL = server.URL
+
+	releases, err := client.FetchPackagistReleases(t.Context(), "drush/drush")
+	if err != nil {
+		t.Fatalf("FetchPackagistReleases returned error: %v", err)
+	}
+
+	var versions []string
+	for _, r := range releases {
+		versions = append(versions, r.Version)
+		if r.Version == "12.x-dev" && r.PHP != ">=8.1" {
+			t.Errorf("expected PHP requirement of 12.x-dev to be >=8.1, got %q", r.PHP)
+		}
+	}
+	if !slices.Contains(versions, "dev-main") || !slices.Contains(versions, "12.x-dev") || !slices.Contains(versions, "13.0.1") {
+		t.Errorf("expected tagged releases and development branches, got %v", versions)
+	}
+}
+
+func TestFetchPackageReleases_Abandoned(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := `{"packages": {"acme/old": [
+			{"version": "1.1.0", "version_normalized": "1.1.0.0", "abandoned": "acme/new"},
+			{"version": "1.0.0", "version_normalized": "1.0.0.0"}
+		]}}`
+		if r.URL.Path == "/p2/acme/gone.json" {
+			body = `{"packages": {"acme/gone": [{"version": "2.0.0", "version_normalized": "2.0.0.0", "abandoned": true}]}}`
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = ""
+	client.PackagistBaseURL = server.URL
+
+	tests := []struct {
+		pkg         string
+		replacement string
+	}{
+		{"acme/old", "acme/new"},
+		{"acme/gone", ""},
+	}
+	for _, tt := range tests {
+		found, err := client.FetchPackageReleases(t.Context(), tt.pkg)
+		if err != nil {
+			t.Fatalf("FetchPackageReleases(%q) returned error: %v", tt.pkg, err)
+		}
+		if !found.Abandoned || found.Replacement != tt.replacement {
+			t.Errorf("FetchPackageReleases(%q): expected abandoned with replacement %q, got %v, %q", tt.pkg, tt.replacement, found.Abandoned, found.Replacement)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words bytes encoding json slices strings
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Patch is a single patch applied to a package by cweagans/composer-patches.
+type Patch struct {
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+// Patches maps composer package names to the patches applied to them.
+type Patches map[string][]Patch
+
+var errPatchesInvalid = errors.New("invalid patches")
+
+// composerExtra represents the parts of the "extra" section of a composer.json used by composer-patches.
+type composerExtra struct {
+	Patches     json.RawMessage `json:"patches"`
+	PatchesFile string          `json:"patches-file"`
+}
+
+// extra decodes the "extra" section of c.
+func (c *ComposerJSON) extra() (composerExtra, error) {
+	var extra composerExtra
+	raw, ok := c.Raw["extra"]
+	if !ok {
+		return extra, nil
+	}
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		return extra, fmt.Errorf("%w: extra: %w", errPatchesInvalid, err)
+	}
+	return extra, nil
+}
+
+// Patches returns the patches defined in "extra.patches" of c.
+// Patches defined in an external file are not included, see [ComposerJSON.PatchesFile].
+func (c *ComposerJSON) Patches() (Patches, error) {
+	extra, err := c.extra()
+	if err != nil {
+		return nil, err
+	}
+	return parsePatches(extra.Patches)
+}
+
+// PatchesFile returns the path of the external patches file from "extra.patches-file", if any.
+// The path is relative to the composer.json.
+func (c *ComposerJSON) PatchesFile() string {
+	extra, err := c.extra()
+	if err != nil {
+		return ""
+	}
+	return extra.PatchesFile
+}
+
+// ParsePatchesFile parses the contents of an external patches file,
+// which holds the patch definitions in a top-level "patches" key.
+func ParsePatchesFile(data []byte) (Patches, error) {
+	var file struct {
+		Patches json.RawMessage `json:"patches"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse patches file: %w", err)
+	}
+	return parsePatches(file.Patches)
+}
+
+// parsePatches parses patch definitions keyed by package name.
+// Each package maps either descriptions to URLs, or holds a list of patch objects.
+func parsePatches(data json.RawMessage) (Patches, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", errPatchesInvalid, err)
+	}
+
+	patches := make(Patches, len(raw))
+	for pkg, definitions := range raw {
+		var list []Patch
+		if bytes.HasPrefix(bytes.TrimSpace(definitions), []byte("[")) {
+			if err := json.Unmarshal(definitions, &list); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", errPatchesInvalid, pkg, err)
+			}
+		} else {
+			var byDescription map[string]string
+			if err := json.Unmarshal(definitions, &byDescription); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", errPatchesInvalid, pkg, err)
+			}
+			for description, url := range byDescription {
+				list = append(list, Patch{Description: description, URL: url})
+			}
+			slices.SortFunc(list, func(a, b Patch) int {
+				return strings.Compare(a.Description, b.Description)
+			})
+		}
+		if len(list) > 0 {
+			patches[pkg] = list
+		}
+	}
+	return patches, nil
+}
+
+// Merge returns the patches of p and other combined.
+func (p Patches) Merge(other Patches) Patches {
+	merged := make(Patches, len(p)+len(other))
+	for pkg, list := range p {
+		merged[pkg] = slices.Clone(list)
+	}
+	for pkg, list := range other {
+		merged[pkg] = append(merged[pkg], list...)
+	}
+	return merged
+}
+
+// Annotate sets the patches of each package that has any.
+func (p Patches) Annotate(pkgs []Package) {
+	for i := range pkgs {
+		if list, ok := p[pkgs[i].Name]; ok {
+			pkgs[i].Patches = list
+		}
+	}
+}
+
+// PatchedUpdates returns the sorted names of patched packages whose constraint
+// would be changed by applying versions to c.
+// Patches of these packages should be reviewed, as they may no longer apply.
+func (p Patches) PatchedUpdates(c *ComposerJSON, versions map[string]string) []string {
+	var names []string
+	for name, version := range versions {
+		if len(p[name]) == 0 {
+			continue
+		}
+		for _, section := range sections {
+			current, ok := (*c.section(section))[name]
+			if ok && current != version {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,125 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words reflect testing github composer drupal update drupalupdate
+import (
+	"reflect"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+const samplePatchedComposerJSON = `{
+	"require": {
+		"drupal/core-recommended": "^10.3",
+		"drupal/gin": "^3.0",
+		"drupal/webform": "^6.2"
+	},
+	"extra": {
+		"patches-file": "patches.json",
+		"patches": {
+			"drupal/gin": {
+				"Fix toolbar": "https://www.drupal.org/files/issues/gin-toolbar.patch",
+				"Add dark mode": "patches/gin-dark.patch"
+			},
+			"drupal/webform": [
+				{"description": "Fix select", "url": "https://www.drupal.org/files/issues/webform-select.patch"}
+			]
+		}
+	}
+}`
+
+func TestComposerJSON_Patches(t *testing.T) {
+	t.Parallel()
+	composer, err := drupalupdate.ParseComposerJSON([]byte(samplePatchedComposerJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patches, err := composer.Patches()
+	if err != nil {
+		t.Fatalf("Patches returned error: %v", err)
+	}
+	want := drupalupdate.Patches{
+		"drupal/gin": {
+			{Description: "Add dark mode", URL: "patches/gin-dark.patch"},
+			{Description: "Fix toolbar", URL: "https://www.drupal.org/files/issues/gin-toolbar.patch"},
+		},
+		"drupal/webform": {
+			{Description: "Fix select", URL: "https://www.drupal.org/files/issues/webform-select.patch"},
+		},
+	}
+	if !reflect.DeepEqual(patches, want) {
+		t.Errorf("Patches() = %+v, want %+v", patches, want)
+	}
+	if got := composer.PatchesFile(); got != "patches.json" {
+		t.Errorf("PatchesFile() = %q, want %q", got, "patches.json")
+	}
+}
+
+func TestComposerJSON_Patches_None(t *testing.T) {
+	t.Parallel()
+	composer, err := drupalupdate.ParseComposerJSON([]byte(`{"require": {}, "extra": {"drupal-scaffold": {}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patches, err := composer.Patches()
+	if err != nil {
+		t.Fatalf("Patches returned error: %v", err)
+	}
+	if len(patches) != 0 {
+		t.Errorf("expected no patches, got %+v", patches)
+	}
+}
+
+func TestParsePatchesFile(t *testing.T) {
+	t.Parallel()
+	patches, err := drupalupdate.ParsePatchesFile([]byte(`{"patches": {"drupal/core": {"Fix cache": "core-cache.patch"}}}`))
+	if err != nil {
+		t.Fatalf("ParsePatchesFile returned error: %v", err)
+	}
+	if len(patches["drupal/core"]) != 1 || patches["drupal/core"][0].URL != "core-cache.patch" {
+		t.Errorf("unexpected patches: %+v", patches)
+	}
+
+	if _, err := drupalupdate.ParsePatchesFile([]byte(`{"patches": {"drupal/core": "core.patch"}}`)); err == nil {
+		t.Error("expected error for invalid patch definitions")
+	}
+}
+
+func TestPatches_AnnotateAndMerge(t *testing.T) {
+	t.Parallel()
+	patches := drupalupdate.Patches{"drupal/gin": {{Description: "a", URL: "a.patch"}}}.
+		Merge(drupalupdate.Patches{"drupal/gin": {{Description: "b", URL: "b.patch"}}})
+
+	pkgs := []drupalupdate.Package{{Name: "drupal/gin"}, {Name: "drupal/webform"}}
+	patches.Annotate(pkgs)
+
+	if len(pkgs[0].Patches) != 2 {
+		t.Errorf("expected 2 patches for drupal/gin, got %+v", pkgs[0].Patches)
+	}
+	if pkgs[1].Patches != nil {
+		t.Errorf("expected no patches for drupal/webform, got %+v", pkgs[1].Patches)
+	}
+}
+
+func TestPatches_PatchedUpdates(t *testing.T) {
+	t.Parallel()
+	composer, err := drupalupdate.ParseComposerJSON([]byte(samplePatchedComposerJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patches, err := composer.Patches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := patches.PatchedUpdates(&composer, map[string]string{
+		"drupal/gin":              "^4.0", // patched and changed
+		"drupal/webform":          "^6.2", // patched, but unchanged
+		"drupal/core-recommended": "^11",  // changed, but not patched
+	})
+	if want := []string{"drupal/gin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PatchedUpdates() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words strconv strings
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PinStrategy determines how the version constraint of a release is written to composer.json.
+type PinStrategy string
+
+// Pin strategies, shown for a release with version "1.2.3".
+const (
+	PinCaret      PinStrategy = "caret"       // "^1.2", the default
+	PinCaretPatch PinStrategy = "caret-patch" // "^1.2.3"
+	PinTilde      PinStrategy = "tilde"       // "~1.2.3"
+	PinExact      PinStrategy = "exact"       // "1.2.3"
+	PinMinimum    PinStrategy = "minimum"     // ">=1.2.3"
+	PinKeep       PinStrategy = "keep"        // the style of the current constraint, see [PinStrategy.ForConstraint]
+)
+
+var errPinStrategyInvalid = errors.New("invalid pin strategy")
+
+// ParsePinStrategy parses the name of a pin strategy.
+// An empty name results in [PinCaret].
+func ParsePinStrategy(s string) (PinStrategy, error) {
+	if s == "" {
+		return PinCaret, nil
+	}
+	switch strategy := PinStrategy(strings.ToLower(s)); strategy {
+	case PinCaret, PinCaretPatch, PinTilde, PinExact, PinMinimum, PinKeep:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("%w: %q", errPinStrategyInvalid, s)
+	}
+}
+
+// ForConstraint resolves [PinKeep] to the strategy matching the style of the current constraint of a package.
+// Other strategies are returned unchanged.
+//
+// The style is taken from the first alternative of the constraint:
+// "~1.2.3" keeps [PinTilde], "^1.2.3" [PinCaretPatch], ">=1.2" [PinMinimum] and "1.2.3" [PinExact].
+// Everything else, including "~1.2" which allows the same releases as "^1.2", results in [PinCaret].
+func (s PinStrategy) ForConstraint(constraint string) PinStrategy {
+	if s != PinKeep {
+		return s
+	}
+
+	atom := strings.TrimSpace(constraintOrRegex.Split(strings.TrimSpace(constraint), 2)[0])
+	atom, _ = splitStabilityFlag(atom)
+	if fields := strings.Fields(atom); len(fields) > 0 {
+		atom = fields[0]
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "==", "=", "^", "~"} {
+		if rest, ok := strings.CutPrefix(atom, prefix); ok {
+			op, atom = prefix, strings.TrimSpace(rest)
+			break
+		}
+	}
+	_, n, wildcard, err := parseConstraintVersion(atom)
+	if err != nil || wildcard {
+		return PinCaret
+	}
+
+	switch {
+	case op == "~" && n >= 3:
+		return PinTilde
+	case op == "^" && n >= 3:
+		return PinCaretPatch
+	case op == ">=":
+		return PinMinimum
+	case op == "" || op == "=" || op == "==":
+		return PinExact
+	default:
+		return PinCaret
+	}
+}
+
+// Pin returns the composer version constraint for this version using the given strategy.
+// [PinCaret] and [PinKeep] result in [Version.VersionPin].
+// Named and numbered development branches are always pinned by [Version.VersionPin].
+// Pre-releases carry a stability flag (e.g. "~1.0.0@RC"), except when pinned exactly (e.g. "1.0.0-RC2").
+//
+//	Major=1, Minor=2, Patch=3, PinTilde → "~1.2.3"
+//	Major=3, Minor=16, PinExact → "3.16.0"
+func (v Version) Pin(strategy PinStrategy) string {
+	if v.Branch != "" || v.Major < 0 || v.Minor == WildcardSegment {
+		return v.VersionPin()
+	}
+
+	version := v.pinVersion()
+	var pin string
+	switch strategy {
+	case PinCaretPatch:
+		pin = "^" + version
+	case PinTilde:
+		pin = "~" + version
+	case PinMinimum:
+		pin = ">=" + version
+	case PinExact:
+		if v.Stability == StabilityStable {
+			return version
+		}
+		pin = version + "-" + v.Stability
+		if v.StabilityNumber >= 0 {
+			pin += strconv.Itoa(v.StabilityNumber)
+		}
+		return pin
+	default:
+		return v.VersionPin()
+	}
+
+	if v.Stability != StabilityStable {
+		pin += "@" + v.Stability
+	}
+	return pin
+}
+
+// pinVersion returns the major, minor and patch segments of v, with missing ones set to 0,
+// and the build segment if present.
+func (v Version) pinVersion() string {
+	version := strconv.Itoa(seg(v.Major)) + "." + strconv.Itoa(seg(v.Minor)) + "." + strconv.Itoa(seg(v.Patch))
+	if v.Build > 0 {
+		version += "." + strconv.Itoa(v.Build)
+	}
+	return version
+}
+
+// ApplyPinStrategy sets the VersionPin of each release according to the given strategy.
+// [PinKeep] is resolved against the current constraint of the package.
+func ApplyPinStrategy(releases []Release, strategy PinStrategy, current string) {
+	strategy = strategy.ForConstraint(current)
+	for i := range releases {
+		releases[i].VersionPin = ParseVersion(releases[i].Version).Pin(strategy)
+	}
+}
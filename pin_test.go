@@ -0,0 +1,123 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words testing github composer drupal update drupalupdate
+import (
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestParsePinStrategy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    drupalupdate.PinStrategy
+		wantErr bool
+	}{
+		{"", drupalupdate.PinCaret, false},
+		{"caret", drupalupdate.PinCaret, false},
+		{"caret-patch", drupalupdate.PinCaretPatch, false},
+		{"Tilde", drupalupdate.PinTilde, false},
+		{"exact", drupalupdate.PinExact, false},
+		{"minimum", drupalupdate.PinMinimum, false},
+		{"keep", drupalupdate.PinKeep, false},
+		{"loose", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := drupalupdate.ParsePinStrategy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePinStrategy(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePinStrategy(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinStrategy_ForConstraint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		constraint string
+		want       drupalupdate.PinStrategy
+	}{
+		{"^5.0", drupalupdate.PinCaret},
+		{"^5.0.3", drupalupdate.PinCaretPatch},
+		{"~2.1.0", drupalupdate.PinTilde},
+		{"~2.1", drupalupdate.PinCaret},
+		{"10.2.5", drupalupdate.PinExact},
+		{"=10.2.5", drupalupdate.PinExact},
+		{">=1.2", drupalupdate.PinMinimum},
+		{">=1.2 <2.0", drupalupdate.PinMinimum},
+		{"~2.1.0@beta", drupalupdate.PinTilde},
+		{"^9.5.0 || ^10", drupalupdate.PinCaretPatch},
+		{"1.2.*", drupalupdate.PinCaret},
+		{"*", drupalupdate.PinCaret},
+		{"dev-main", drupalupdate.PinCaret},
+		{"", drupalupdate.PinCaret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.PinKeep.ForConstraint(tt.constraint); got != tt.want {
+				t.Errorf("PinKeep.ForConstraint(%q) = %q, want %q", tt.constraint, got, tt.want)
+			}
+		})
+	}
+
+	if got := drupalupdate.PinExact.ForConstraint("^5.0"); got != drupalupdate.PinExact {
+		t.Errorf("PinExact.ForConstraint(%q) = %q, want %q", "^5.0", got, drupalupdate.PinExact)
+	}
+}
+
+func TestVersion_Pin(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		version  string
+		strategy drupalupdate.PinStrategy
+		want     string
+	}{
+		{"5.0.3", drupalupdate.PinCaret, "^5.0"},
+		{"5.0.3", drupalupdate.PinKeep, "^5.0"},
+		{"5.0.3", drupalupdate.PinCaretPatch, "^5.0.3"},
+		{"5.0.3", drupalupdate.PinTilde, "~5.0.3"},
+		{"5.0.3", drupalupdate.PinExact, "5.0.3"},
+		{"5.0.3", drupalupdate.PinMinimum, ">=5.0.3"},
+		{"v5.0.3", drupalupdate.PinExact, "5.0.3"},
+		{"8.x-3.16", drupalupdate.PinExact, "3.16.0"},
+		{"8.x-3.16", drupalupdate.PinTilde, "~3.16.0"},
+		{"1.2.3.4", drupalupdate.PinExact, "1.2.3.4"},
+		{"3.0.0-rc21", drupalupdate.PinExact, "3.0.0-RC21"},
+		{"3.0.0-rc21", drupalupdate.PinTilde, "~3.0.0@RC"},
+		{"8.x-4.0-beta1", drupalupdate.PinCaretPatch, "^4.0.0@beta"},
+		{"1.x-dev", drupalupdate.PinExact, "^1@dev"},
+		{"dev-main", drupalupdate.PinTilde, "dev-main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+"_"+string(tt.strategy), func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.ParseVersion(tt.version).Pin(tt.strategy); got != tt.want {
+				t.Errorf("ParseVersion(%q).Pin(%q) = %q, want %q", tt.version, tt.strategy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPinStrategy(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{
+		{Version: "10.4.3", VersionPin: "^10.4"},
+		{Version: "11.1.0", VersionPin: "^11.1"},
+	}
+	drupalupdate.ApplyPinStrategy(releases, drupalupdate.PinKeep, "10.2.5")
+
+	want := []string{"10.4.3", "11.1.0"}
+	for i, release := range releases {
+		if release.VersionPin != want[i] {
+			t.Errorf("release %s: expected version pin %q, got %q", release.Version, want[i], release.VersionPin)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words encoding json
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PlatformPHP returns the PHP version configured in "config.platform.php" of c, if any.
+func (c *ComposerJSON) PlatformPHP() (string, error) {
+	raw, ok := c.Raw["config"]
+	if !ok {
+		return "", nil
+	}
+	var config struct {
+		Platform map[string]json.RawMessage `json:"platform"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return "", fmt.Errorf("config: %w", err)
+	}
+
+	rawPHP, ok := config.Platform["php"]
+	if !ok {
+		return "", nil
+	}
+	var php string
+	if err := json.Unmarshal(rawPHP, &php); err != nil {
+		return "", fmt.Errorf("config.platform.php: %w", err)
+	}
+	return php, nil
+}
+
+// phpAllows reports whether the given PHP version satisfies the PHP requirement of a release.
+// An empty version or requirement, and requirements that cannot be parsed, allow any release.
+func phpAllows(php, requirement string) bool {
+	if php == "" || requirement == "" {
+		return true
+	}
+	c, err := ParseConstraint(requirement)
+	if err != nil {
+		return true
+	}
+	return c.Matches(ParseVersion(php))
+}
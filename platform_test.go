@@ -0,0 +1,88 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest testing github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestComposerJSON_PlatformPHP(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"require": {}}`, ""},
+		{`{"config": {"sort-packages": true}}`, ""},
+		{`{"config": {"platform": {"php": "8.1.27", "ext-gd": "1.0"}}}`, "8.1.27"},
+	}
+	for _, tt := range tests {
+		composer, err := drupalupdate.ParseComposerJSON([]byte(tt.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := composer.PlatformPHP()
+		if err != nil {
+			t.Fatalf("PlatformPHP(%s) returned error: %v", tt.json, err)
+		}
+		if got != tt.want {
+			t.Errorf("PlatformPHP(%s) = %q, want %q", tt.json, got, tt.want)
+		}
+	}
+}
+
+func TestComposerJSON_PlatformPHP_Invalid(t *testing.T) {
+	t.Parallel()
+	composer, err := drupalupdate.ParseComposerJSON([]byte(`{"config": {"platform": {"php": 8}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := composer.PlatformPHP(); err == nil {
+		t.Error("expected error for a non-string platform PHP version")
+	}
+}
+
+func TestFetchPackagistReleases_PlatformPHP(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`{"packages": {"acme/lib": [
+			{"version": "2.1.0", "version_normalized": "2.1.0.0", "require": {"php": ">=8.3"}},
+			{"version": "2.0.0", "version_normalized": "2.0.0.0", "require": {"php": ">=8.1"}},
+			{"version": "1.5.0", "version_normalized": "1.5.0.0", "require": {"php": "^7.4 || ^8.0"}}
+		]}}`)); err != nil {
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := drupalupdate.NewClient()
+	client.PackagistBaseURL = server.URL
+
+	releases, err := client.FetchPackagistReleases(t.Context(), "acme/lib")
+	if err != nil {
+		t.Fatalf("FetchPackagistReleases returned error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "2.1.0" || releases[0].PHP != ">=8.3" {
+		t.Errorf("expected all releases without a platform, got %+v", releases)
+	}
+
+	releases, err = client.WithPlatformPHP("8.2.10").FetchPackagistReleases(t.Context(), "acme/lib")
+	if err != nil {
+		t.Fatalf("FetchPackagistReleases returned error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "2.0.0" || releases[1].Version != "1.5.0" {
+		t.Errorf("expected the latest releases installable on PHP 8.2, got %+v", releases)
+	}
+
+	releases, err = client.WithPlatformPHP("7.4").FetchPackagistReleases(t.Context(), "acme/lib")
+	if err != nil {
+		t.Fatalf("FetchPackagistReleases returned error: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Version != "1.5.0" {
+		t.Errorf("expected only 1.5.0 on PHP 7.4, got %+v", releases)
+	}
+}
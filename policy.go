@@ -0,0 +1,139 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words errors http netip slices strings sync syscall time
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// AnyHost is the entry of [RepositoryPolicy.Hosts] allowing repositories on any host.
+const AnyHost = "*"
+
+// RepositoryPolicy restricts the custom repositories a [Client] fetches from,
+// e.g. the repositories supplied by the clients of a [Server].
+// The zero value allows no repository, use [NewRepositoryPolicy] to allow some.
+//
+// Composer repositories on other hosts are ignored, and their metadata may only be fetched from allowed hosts.
+// Unless AllowPrivate is set, connections to loopback, private and link-local addresses are refused,
+// regardless of the host name they were resolved from.
+// The credentials of the client are not sent to these repositories, since their URLs may be chosen by others.
+// The repositories of drupal.org and Packagist configured on the client are not restricted.
+type RepositoryPolicy struct {
+	Hosts        []string // hosts repositories may be fetched from, [AnyHost] allows every host
+	AllowPrivate bool     // allow loopback, private and link-local addresses, e.g. of a Satis instance in the local network
+
+	httpClientOnce sync.Once
+	httpClient     *http.Client // client enforcing the policy on every connection and redirect, see [RepositoryPolicy.client]
+}
+
+var (
+	errRepositoryForbidden = errors.New("repository not allowed")
+
+	// sharedAddressSpace is the carrier-grade NAT range, which is not covered by [netip.Addr.IsPrivate].
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// NewRepositoryPolicy returns a RepositoryPolicy allowing repositories on the given hosts.
+// Without hosts, no custom repository is fetched from.
+func NewRepositoryPolicy(hosts []string, allowPrivate bool) *RepositoryPolicy {
+	return &RepositoryPolicy{Hosts: hosts, AllowPrivate: allowPrivate}
+}
+
+// client returns the HTTP client enforcing p on every connection and redirect.
+// It is created on first use and shared by all fetches using p, so that connections are reused.
+func (p *RepositoryPolicy) client() *http.Client {
+	p.httpClientOnce.Do(func() {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: p.checkAddress}
+		p.httpClient = &http.Client{
+			// no proxy, since it would connect on behalf of the client without checking the address
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if err := checkRedirect(req, via); err != nil {
+					return err
+				}
+				return p.checkURL(req.URL.String())
+			},
+		}
+	})
+	return p.httpClient
+}
+
+// permits reports whether repo may be used.
+// Repositories that are not fetched from, like "package" repositories and the drupal.org composer repository, are always permitted.
+// A nil policy permits every repository.
+func (p *RepositoryPolicy) permits(repo Repository) bool {
+	if p == nil || repo.Type != RepositoryComposer || repo.isDrupalOrg() {
+		return true
+	}
+	return p.checkURL(repo.URL) == nil
+}
+
+// checkURL returns an error if p does not allow fetching from rawURL.
+// Only http and https URLs on the hosts of p are allowed. A nil policy allows every URL.
+func (p *RepositoryPolicy) checkURL(rawURL string) error {
+	if p == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRepositoryForbidden, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", errRepositoryForbidden, u.Scheme)
+	}
+	host := u.Hostname()
+	if !slices.Contains(p.Hosts, AnyHost) && !slices.ContainsFunc(p.Hosts, func(allowed string) bool { return strings.EqualFold(allowed, host) }) {
+		return fmt.Errorf("%w: host %q", errRepositoryForbidden, host)
+	}
+	return nil
+}
+
+// checkAddress is the [net.Dialer] Control function of p, refusing connections to non-public addresses unless they are allowed.
+func (p *RepositoryPolicy) checkAddress(network, address string, _ syscall.RawConn) error {
+	if p.AllowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRepositoryForbidden, err)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRepositoryForbidden, err)
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("%w: %s address %s", errRepositoryForbidden, network, addr)
+	}
+	return nil
+}
+
+// repositoryClient returns the client used to fetch from the custom repositories of c.
+// With a RepositoryPolicy, it is a copy of c without credentials, fetching using the HTTP client enforcing the policy.
+func (c *Client) repositoryClient() *Client {
+	if c.RepositoryPolicy == nil {
+		return c
+	}
+	clone := *c
+	clone.HTTPClient = c.RepositoryPolicy.client()
+	clone.Auth = Auth{}
+	return &clone
+}
@@ -0,0 +1,161 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest strings sync atomic testing github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+// newCountingServer wraps handler, counting the requests it receives.
+func newCountingServer(t *testing.T, handler http.Handler, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchReleases_RepositoryPolicy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		policy  func(host string) *drupalupdate.RepositoryPolicy
+		wantErr bool
+		fetched bool // whether the repository receives requests
+	}{
+		{
+			name:    "no policy",
+			policy:  func(string) *drupalupdate.RepositoryPolicy { return nil },
+			fetched: true,
+		},
+		{
+			name: "allowed host",
+			policy: func(host string) *drupalupdate.RepositoryPolicy {
+				return drupalupdate.NewRepositoryPolicy([]string{host}, true)
+			},
+			fetched: true,
+		},
+		{
+			name: "any host",
+			policy: func(string) *drupalupdate.RepositoryPolicy {
+				return drupalupdate.NewRepositoryPolicy([]string{drupalupdate.AnyHost}, true)
+			},
+			fetched: true,
+		},
+		{
+			name:    "no hosts",
+			policy:  func(string) *drupalupdate.RepositoryPolicy { return drupalupdate.NewRepositoryPolicy(nil, true) },
+			wantErr: true,
+		},
+		{
+			name: "other host",
+			policy: func(string) *drupalupdate.RepositoryPolicy {
+				return drupalupdate.NewRepositoryPolicy([]string{"satis.example.com"}, true)
+			},
+			wantErr: true,
+		},
+		{
+			name: "loopback address",
+			policy: func(host string) *drupalupdate.RepositoryPolicy {
+				return drupalupdate.NewRepositoryPolicy([]string{host}, false)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			satis := newSatisServer(t)
+			t.Cleanup(satis.Close)
+			var requests atomic.Int32
+			repo := newCountingServer(t, satis.Config.Handler, &requests)
+
+			u, err := url.Parse(repo.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := drupalupdate.NewClient().WithRepositoryPolicy(tt.policy(u.Hostname()))
+			client.Repositories = drupalupdate.Repositories{
+				List:              []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: repo.URL}},
+				PackagistDisabled: true,
+			}
+
+			releases, err := client.FetchReleases(t.Context(), "acme/lib")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchReleases returned error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(releases) == 0 {
+				t.Error("expected releases")
+			}
+			if fetched := requests.Load() > 0; fetched != tt.fetched {
+				t.Errorf("expected repository to be fetched: %v, got %d requests", tt.fetched, requests.Load())
+			}
+		})
+	}
+}
+
+func TestFetchReleases_RepositoryPolicy_Scheme(t *testing.T) {
+	t.Parallel()
+	client := drupalupdate.NewClient().WithRepositoryPolicy(drupalupdate.NewRepositoryPolicy([]string{drupalupdate.AnyHost}, true))
+	client.Repositories = drupalupdate.Repositories{
+		List:              []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: "file:///etc"}},
+		PackagistDisabled: true,
+	}
+	if _, err := client.FetchReleases(t.Context(), "acme/lib"); err == nil {
+		t.Error("expected a repository with a file URL to be ignored")
+	}
+}
+
+func TestFetchReleases_RepositoryPolicy_MetadataURL(t *testing.T) {
+	t.Parallel()
+	var internalRequests atomic.Int32
+	internal := newCountingServer(t, http.NotFoundHandler(), &internalRequests)
+	t.Cleanup(func() {
+		// runs after all subtests
+		if got := internalRequests.Load(); got != 0 {
+			t.Errorf("expected hosts not allowed by the policy not to be fetched, got %d requests", got)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		metadata string // metadata-url of the repository, or "" to redirect to the internal server
+	}{
+		{name: "absolute metadata-url", metadata: strings.Replace(internal.URL, "127.0.0.1", "localhost", 1) + "/p2/%package%.json"},
+		{name: "redirect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			repo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/packages.json" && tt.metadata != "" {
+					if _, err := w.Write([]byte(`{"packages": [], "metadata-url": "` + tt.metadata + `"}`)); err != nil {
+						return
+					}
+					return
+				}
+				http.Redirect(w, r, strings.Replace(internal.URL, "127.0.0.1", "localhost", 1)+r.URL.Path, http.StatusFound)
+			}))
+			t.Cleanup(repo.Close)
+
+			client := drupalupdate.NewClient().WithRepositoryPolicy(drupalupdate.NewRepositoryPolicy([]string{"127.0.0.1"}, true))
+			client.Repositories = drupalupdate.Repositories{
+				List:              []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: repo.URL}},
+				PackagistDisabled: true,
+			}
+			if _, err := client.FetchReleases(t.Context(), "acme/lib"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
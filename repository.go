@@ -0,0 +1,421 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words bytes context encoding json errors maps http path regexp slices strings sync
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Repository types that can be declared in the "repositories" section of a composer.json.
+const (
+	RepositoryComposer = "composer" // a composer repository, e.g. packagist.org or a Satis instance
+	RepositoryVCS      = "vcs"      // a version control repository
+	RepositoryPath     = "path"     // a local directory
+	RepositoryPackage  = "package"  // an inline package definition
+)
+
+// Repository is a single entry of the "repositories" section of a composer.json.
+type Repository struct {
+	Type      string          `json:"type"`
+	URL       string          `json:"url,omitempty"`
+	Canonical *bool           `json:"canonical,omitempty"` // defaults to true
+	Only      []string        `json:"only,omitempty"`      // package name patterns served by this repository
+	Exclude   []string        `json:"exclude,omitempty"`   // package name patterns not served by this repository
+	Package   json.RawMessage `json:"package,omitempty"`   // inline package definition(s), only for "package" repositories
+}
+
+// Repositories is the parsed "repositories" section of a composer.json.
+// The zero value looks up packages on drupal.org and Packagist only.
+type Repositories struct {
+	List              []Repository // repositories in order of priority
+	PackagistDisabled bool         // "packagist.org" was set to false
+}
+
+var (
+	errRepositoryInvalid     = errors.New("invalid repositories")
+	errRepositoryUnsupported = errors.New("releases cannot be fetched from this repository")
+	errPackageNotFound       = errors.New("package not found in any repository")
+
+	// errNotInRepository is returned internally when a repository does not provide a package.
+	errNotInRepository = errors.New("package not in repository")
+)
+
+// Repositories returns the parsed "repositories" section of c.
+func (c *ComposerJSON) Repositories() (Repositories, error) {
+	raw, ok := c.Raw["repositories"]
+	if !ok {
+		return Repositories{}, nil
+	}
+	return ParseRepositories(raw)
+}
+
+// ParseRepositories parses the "repositories" section of a composer.json.
+// Both the list form and the object form (keyed by repository name) are supported,
+// and the order of repositories is preserved.
+func ParseRepositories(data []byte) (Repositories, error) {
+	var repos Repositories
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return repos, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return repos, fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+	}
+
+	switch tok {
+	case json.Delim('['):
+		for dec.More() {
+			var entry json.RawMessage
+			if err := dec.Decode(&entry); err != nil {
+				return repos, fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+			}
+			if err := repos.add("", entry); err != nil {
+				return repos, err
+			}
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return repos, fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+			}
+			var entry json.RawMessage
+			if err := dec.Decode(&entry); err != nil {
+				return repos, fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+			}
+			name, _ := key.(string) // object keys are always strings
+			if err := repos.add(name, entry); err != nil {
+				return repos, err
+			}
+		}
+	default:
+		return repos, fmt.Errorf("%w: expected a list or an object", errRepositoryInvalid)
+	}
+	return repos, nil
+}
+
+// add adds a single repository entry with the given name (empty in the list form).
+func (r *Repositories) add(name string, entry json.RawMessage) error {
+	if bytes.Equal(entry, []byte("false")) {
+		if isPackagistName(name) {
+			r.PackagistDisabled = true
+		}
+		return nil
+	}
+
+	// {"packagist.org": false} disables packagist in the list form
+	var disable map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &disable); err != nil {
+		return fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+	}
+	if _, hasType := disable["type"]; !hasType {
+		for key, value := range disable {
+			if isPackagistName(key) && bytes.Equal(value, []byte("false")) {
+				r.PackagistDisabled = true
+				return nil
+			}
+		}
+	}
+
+	var repo Repository
+	if err := json.Unmarshal(entry, &repo); err != nil {
+		return fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+	}
+	if repo.Type == "" {
+		return fmt.Errorf("%w: repository without type", errRepositoryInvalid)
+	}
+	r.List = append(r.List, repo)
+	return nil
+}
+
+// isPackagistName checks if name refers to the default packagist.org repository.
+func isPackagistName(name string) bool {
+	return name == "packagist.org" || name == "packagist"
+}
+
+// String returns a human-readable description of the repository.
+func (r Repository) String() string {
+	if r.URL == "" {
+		return r.Type
+	}
+	return r.Type + " " + r.URL
+}
+
+// isCanonical reports whether lower-priority repositories are ignored once r provides a package.
+func (r Repository) isCanonical() bool {
+	return r.Canonical == nil || *r.Canonical
+}
+
+// isDrupalOrg reports whether r is the drupal.org composer repository.
+// Packages from it are looked up using the drupal.org release history instead.
+func (r Repository) isDrupalOrg() bool {
+	u, err := url.Parse(r.URL)
+	return err == nil && r.Type == RepositoryComposer && u.Host == "packages.drupal.org"
+}
+
+// allows checks the "only" and "exclude" filters of r for the given package.
+func (r Repository) allows(pkg string) bool {
+	if len(r.Only) > 0 && !slices.ContainsFunc(r.Only, func(pattern string) bool { return matchPackagePattern(pattern, pkg) }) {
+		return false
+	}
+	return !slices.ContainsFunc(r.Exclude, func(pattern string) bool { return matchPackagePattern(pattern, pkg) })
+}
+
+// matchPackagePattern checks if pkg matches a composer package pattern, where "*" matches any sequence of characters.
+func matchPackagePattern(pattern, pkg string) bool {
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expr, pkg)
+	return err == nil && matched
+}
+
+// servesByName reports whether a repository that cannot be queried (e.g. "vcs" or "path") serves pkg.
+// Since the package name is only known from the repository contents,
+// it is guessed from an explicit "only" filter or the last element of the URL.
+func (r Repository) servesByName(pkg string) bool {
+	if slices.Contains(r.Only, pkg) {
+		return true
+	}
+	base := strings.TrimSuffix(path.Base(strings.TrimRight(r.URL, "/")), ".git")
+	_, name, _ := strings.Cut(pkg, "/")
+	return base != "" && base == name
+}
+
+// =============================================================================
+// Fetching from repositories
+// =============================================================================
+
+// fetchRepositoryReleases fetches the releases of pkg from a single repository.
+// For the drupal.org repository, the status and supported branches of the project are included.
+// It returns errNotInRepository if the repository does not provide pkg.
+func (c *Client) fetchRepositoryReleases(ctx context.Context, repo Repository, pkg string) (PackageReleases, error) {
+	var releases []Release
+	var err error
+	switch repo.Type {
+	case RepositoryComposer:
+		if repo.isDrupalOrg() {
+			if _, ok := drupalModuleName(pkg); !ok {
+				return PackageReleases{}, errNotInRepository
+			}
+			project, err := c.fetchDrupalPackage(ctx, pkg)
+			if err != nil {
+				return PackageReleases{}, err
+			}
+			return project.packageReleases(), nil
+		}
+		return c.repositoryClient().fetchComposerRepositoryReleases(ctx, repo, pkg)
+	case RepositoryPackage:
+		releases, err = repo.inlineReleases(pkg, c.releaseFilter())
+	default:
+		// vcs, path, artifact, git, github, ...
+		if !repo.servesByName(pkg) {
+			return PackageReleases{}, errNotInRepository
+		}
+		err = fmt.Errorf("%w: %s is served by %s", errRepositoryUnsupported, pkg, repo)
+	}
+	if err != nil {
+		return PackageReleases{}, err
+	}
+	return PackageReleases{Releases: releases}, nil
+}
+
+// composerRepositoryIndex represents the packages.json file at the root of a composer repository.
+type composerRepositoryIndex struct {
+	MetadataURL              string          `json:"metadata-url"`
+	AvailablePackages        []string        `json:"available-packages"`
+	AvailablePackagePatterns []string        `json:"available-package-patterns"`
+	Packages                 json.RawMessage `json:"packages"` // inline packages, e.g. from older Satis versions
+}
+
+// provides checks if the index lists pkg as available.
+// Repositories that do not list their packages are assumed to provide every package.
+func (idx composerRepositoryIndex) provides(pkg string) bool {
+	if len(idx.AvailablePackages) == 0 && len(idx.AvailablePackagePatterns) == 0 {
+		return true
+	}
+	return slices.Contains(idx.AvailablePackages, pkg) ||
+		slices.ContainsFunc(idx.AvailablePackagePatterns, func(pattern string) bool { return matchPackagePattern(pattern, pkg) })
+}
+
+// repositoryIndexes holds the packages.json files of composer repositories fetched during a single [Client.FetchAll],
+// so that they are fetched once instead of once per package.
+type repositoryIndexes struct {
+	mu      sync.Mutex
+	entries map[repositoryIndexKey]*repositoryIndexEntry
+}
+
+// repositoryIndexKey identifies a packages.json file, fetched using a specific HTTP client, e.g. one enforcing a [RepositoryPolicy].
+type repositoryIndexKey struct {
+	client *http.Client
+	url    string
+}
+
+// repositoryIndexEntry is a packages.json file fetched once.
+type repositoryIndexEntry struct {
+	once sync.Once
+	idx  composerRepositoryIndex
+	err  error
+}
+
+// fetchComposerRepositoryIndex fetches the packages.json file of the composer repository at base.
+// During [Client.FetchAll], each file is only fetched once.
+func (c *Client) fetchComposerRepositoryIndex(ctx context.Context, base string) (composerRepositoryIndex, error) {
+	indexURL := base + "/packages.json"
+	fetch := func() (composerRepositoryIndex, error) {
+		return fetchResponse(ctx, c, indexURL, func(body io.Reader) (idx composerRepositoryIndex, err error) {
+			if err := json.NewDecoder(body).Decode(&idx); err != nil {
+				return idx, fmt.Errorf("decode JSON: %w", err)
+			}
+			return idx, nil
+		})
+	}
+	if c.indexes == nil {
+		return fetch()
+	}
+
+	key := repositoryIndexKey{client: c.HTTPClient, url: indexURL}
+	c.indexes.mu.Lock()
+	entry, ok := c.indexes.entries[key]
+	if !ok {
+		entry = &repositoryIndexEntry{}
+		c.indexes.entries[key] = entry
+	}
+	c.indexes.mu.Unlock()
+
+	entry.once.Do(func() { entry.idx, entry.err = fetch() })
+	return entry.idx, entry.err
+}
+
+// fetchComposerRepositoryReleases fetches releases of pkg from a composer repository such as Satis.
+// It returns errNotInRepository if the repository does not provide pkg.
+// With a RepositoryPolicy, its metadata is only fetched from the hosts permitted by it.
+func (c *Client) fetchComposerRepositoryReleases(ctx context.Context, repo Repository, pkg string) (PackageReleases, error) {
+	base := strings.TrimRight(repo.URL, "/")
+	idx, err := c.fetchComposerRepositoryIndex(ctx, base)
+	if err != nil {
+		return PackageReleases{}, err
+	}
+	if !idx.provides(pkg) {
+		return PackageReleases{}, errNotInRepository
+	}
+
+	if idx.MetadataURL != "" {
+		// keep the placeholder, it is replaced for each metadata file of pkg
+		metadataURL := resolveMetadataURL(base, idx.MetadataURL, "%package%")
+		if err := c.RepositoryPolicy.checkURL(resolveMetadataURL(base, idx.MetadataURL, pkg)); err != nil {
+			return PackageReleases{}, fmt.Errorf("metadata-url: %w", err)
+		}
+		found, err := c.fetchComposerMetadata(ctx, metadataURL, pkg)
+		if errors.Is(err, errHTTPNotFound) {
+			return PackageReleases{}, errNotInRepository
+		}
+		return found, err
+	}
+
+	// fall back to packages listed inline in packages.json
+	var inline map[string]map[string]packagistVersion
+	if !bytes.HasPrefix(bytes.TrimSpace(idx.Packages), []byte("{")) {
+		return PackageReleases{}, errNotInRepository
+	}
+	if err := json.Unmarshal(idx.Packages, &inline); err != nil {
+		return PackageReleases{}, fmt.Errorf("decode inline packages: %w", err)
+	}
+	versions, ok := inline[pkg]
+	if !ok {
+		return PackageReleases{}, errNotInRepository
+	}
+	return PackageReleases{Releases: packagistReleases(pkg, slices.Collect(maps.Values(versions)), c.releaseFilter())}, nil
+}
+
+// resolveMetadataURL resolves the "metadata-url" template of a composer repository for pkg.
+// Like composer, URLs starting with "/" are relative to the host of the repository.
+func resolveMetadataURL(base, template, pkg string) string {
+	resolved := strings.ReplaceAll(template, "%package%", pkg)
+	if !strings.HasPrefix(resolved, "/") {
+		return resolved
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + resolved
+	}
+	return u.Scheme + "://" + u.Host + resolved
+}
+
+// inlineReleases returns the releases of pkg defined inline in a "package" repository.
+func (r Repository) inlineReleases(pkg string, filter releaseFilter) ([]Release, error) {
+	type inlinePackage struct {
+		Name string `json:"name"`
+		packagistVersion
+	}
+
+	var packages []inlinePackage
+	if bytes.HasPrefix(bytes.TrimSpace(r.Package), []byte("[")) {
+		if err := json.Unmarshal(r.Package, &packages); err != nil {
+			return nil, fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+		}
+	} else {
+		var single inlinePackage
+		if err := json.Unmarshal(r.Package, &single); err != nil {
+			return nil, fmt.Errorf("%w: %w", errRepositoryInvalid, err)
+		}
+		packages = append(packages, single)
+	}
+
+	var versions []packagistVersion
+	for _, p := range packages {
+		if p.Name == pkg {
+			versions = append(versions, p.packagistVersion)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, errNotInRepository
+	}
+	return packagistReleases(pkg, versions, filter), nil
+}
+
+// packagistReleases returns the releases offered by filter from versions in any order.
+func packagistReleases(pkg string, versions []packagistVersion, filter releaseFilter) []Release {
+	for i := range versions {
+		if versions[i].VersionNormalized == "" {
+			versions[i].VersionNormalized = strings.TrimPrefix(versions[i].Version, "v")
+		}
+	}
+	slices.SortStableFunc(versions, func(a, b packagistVersion) int {
+		return ParseVersion(strings.TrimPrefix(b.Version, "v")).Compare(ParseVersion(strings.TrimPrefix(a.Version, "v")))
+	})
+
+	releases := filter.selectPackagist(pkg, versions)
+	sortReleases(releases)
+	return releases
+}
+
+// mergeReleases merges releases from several repositories, keeping the first release of each version.
+func mergeReleases(releases []Release) []Release {
+	seen := make(map[string]bool, len(releases))
+	merged := releases[:0]
+	for _, release := range releases {
+		if seen[release.Version] {
+			continue
+		}
+		seen[release.Version] = true
+		merged = append(merged, release)
+	}
+	sortReleases(merged)
+	return merged
+}
@@ -0,0 +1,47 @@
+package drupalupdate
+
+import "testing"
+
+func TestResolveMetadataURL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		base, template, want string
+	}{
+		{"https://satis.example.com/repo", "/p2/%package%.json", "https://satis.example.com/p2/acme/lib.json"},
+		{"https://satis.example.com", "https://cdn.example.com/p2/%package%.json", "https://cdn.example.com/p2/acme/lib.json"},
+	}
+	for _, tt := range tests {
+		if got := resolveMetadataURL(tt.base, tt.template, "acme/lib"); got != tt.want {
+			t.Errorf("resolveMetadataURL(%q, %q) = %q, want %q", tt.base, tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_ServesByName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		repo Repository
+		pkg  string
+		want bool
+	}{
+		{Repository{Type: RepositoryVCS, URL: "https://github.com/acme/theme.git"}, "acme/theme", true},
+		{Repository{Type: RepositoryPath, URL: "../packages/theme/"}, "acme/theme", true},
+		{Repository{Type: RepositoryVCS, URL: "https://github.com/acme/theme.git"}, "acme/lib", false},
+		{Repository{Type: RepositoryVCS, URL: "https://git.example.com/x.git", Only: []string{"acme/lib"}}, "acme/lib", true},
+	}
+	for _, tt := range tests {
+		if got := tt.repo.servesByName(tt.pkg); got != tt.want {
+			t.Errorf("%s servesByName(%q) = %v, want %v", tt.repo, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_IsDrupalOrg(t *testing.T) {
+	t.Parallel()
+	if !(Repository{Type: RepositoryComposer, URL: "https://packages.drupal.org/8"}).isDrupalOrg() {
+		t.Error("expected packages.drupal.org to be recognized")
+	}
+	if (Repository{Type: RepositoryComposer, URL: "https://satis.example.com"}).isDrupalOrg() {
+		t.Error("expected other composer repositories not to be recognized")
+	}
+}
@@ -0,0 +1,222 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest testing github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+// =============================================================================
+// ParseRepositories
+// =============================================================================
+
+func TestParseRepositories_List(t *testing.T) {
+	t.Parallel()
+	repos, err := drupalupdate.ParseRepositories([]byte(`[
+		{"type": "composer", "url": "https://packages.drupal.org/8"},
+		{"type": "vcs", "url": "https://github.com/acme/theme.git"},
+		{"packagist.org": false}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseRepositories returned error: %v", err)
+	}
+	if !repos.PackagistDisabled {
+		t.Error("expected packagist.org to be disabled")
+	}
+	if len(repos.List) != 2 {
+		t.Fatalf("expected 2 repositories, got %d", len(repos.List))
+	}
+	if repos.List[0].Type != drupalupdate.RepositoryComposer || repos.List[1].Type != drupalupdate.RepositoryVCS {
+		t.Errorf("unexpected repository types: %q, %q", repos.List[0].Type, repos.List[1].Type)
+	}
+}
+
+func TestParseRepositories_Object(t *testing.T) {
+	t.Parallel()
+	repos, err := drupalupdate.ParseRepositories([]byte(`{
+		"satis": {"type": "composer", "url": "https://satis.example.com"},
+		"drupal": {"type": "composer", "url": "https://packages.drupal.org/8"},
+		"packagist.org": false
+	}`))
+	if err != nil {
+		t.Fatalf("ParseRepositories returned error: %v", err)
+	}
+	if !repos.PackagistDisabled {
+		t.Error("expected packagist.org to be disabled")
+	}
+	// order must be preserved, as it determines priority
+	if len(repos.List) != 2 || repos.List[0].URL != "https://satis.example.com" {
+		t.Errorf("unexpected repositories: %+v", repos.List)
+	}
+}
+
+func TestParseRepositories_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{`"composer"`, `[{"url": "https://example.com"}]`, `[42]`} {
+		if _, err := drupalupdate.ParseRepositories([]byte(input)); err == nil {
+			t.Errorf("ParseRepositories(%s) expected error, got nil", input)
+		}
+	}
+}
+
+func TestComposerJSON_Repositories(t *testing.T) {
+	t.Parallel()
+	composer, err := drupalupdate.ParseComposerJSON([]byte(`{"require": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := composer.Repositories()
+	if err != nil {
+		t.Fatalf("Repositories returned error: %v", err)
+	}
+	if len(repos.List) != 0 || repos.PackagistDisabled {
+		t.Errorf("expected no repositories, got %+v", repos)
+	}
+}
+
+// =============================================================================
+// FetchReleases with custom repositories
+// =============================================================================
+
+// newSatisServer creates a mock composer repository serving acme/lib via metadata-url.
+func newSatisServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body string
+		switch r.URL.Path {
+		case "/packages.json":
+			body = `{"packages": [], "metadata-url": "/p2/%package%.json", "available-packages": ["acme/lib"]}`
+		case "/p2/acme/lib.json":
+			body = `{"packages": {"acme/lib": [
+				{"version": "2.1.0", "version_normalized": "2.1.0.0"},
+				{"version": "2.0.0", "version_normalized": "2.0.0.0"},
+				{"version": "1.4.2", "version_normalized": "1.4.2.0"}
+			]}}`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			return
+		}
+	}))
+}
+
+func TestFetchReleases_ComposerRepository(t *testing.T) {
+	t.Parallel()
+	satis := newSatisServer(t)
+	defer satis.Close()
+
+	client := drupalupdate.NewClient()
+	client.PackagistBaseURL = ""
+	client.Repositories = drupalupdate.Repositories{
+		List: []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: satis.URL}},
+	}
+
+	releases, err := client.FetchReleases(t.Context(), "acme/lib")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "2.1.0" || releases[1].Version != "1.4.2" {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestFetchReleases_FallsBackToPackagist(t *testing.T) {
+	t.Parallel()
+	satis := newSatisServer(t)
+	defer satis.Close()
+
+	packagist := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/p2/drush/drush.json" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(samplePackagistJSON)); err != nil {
+			return
+		}
+	}))
+	defer packagist.Close()
+
+	client := drupalupdate.NewClient()
+	client.PackagistBaseURL = packagist.URL
+	client.Repositories = drupalupdate.Repositories{
+		List: []drupalupdate.Repository{{Type: drupalupdate.RepositoryComposer, URL: satis.URL}},
+	}
+
+	releases, err := client.FetchReleases(t.Context(), "drush/drush")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) != 3 || releases[0].Version != "13.0.1" {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+
+	// once packagist.org is disabled, drush cannot be found anymore
+	client.Repositories.PackagistDisabled = true
+	if _, err := client.FetchReleases(t.Context(), "drush/drush"); err == nil {
+		t.Error("expected error with packagist.org disabled")
+	}
+}
+
+func TestFetchReleases_PackageRepository(t *testing.T) {
+	t.Parallel()
+	client := drupalupdate.NewClient()
+	client.Repositories = drupalupdate.Repositories{
+		PackagistDisabled: true,
+		List: []drupalupdate.Repository{{
+			Type: drupalupdate.RepositoryPackage,
+			Package: []byte(`[
+				{"name": "acme/assets", "version": "1.0.0"},
+				{"name": "acme/assets", "version": "1.2.0"},
+				{"name": "acme/other", "version": "3.0.0"}
+			]`),
+		}},
+	}
+
+	releases, err := client.FetchReleases(t.Context(), "acme/assets")
+	if err != nil {
+		t.Fatalf("FetchReleases returned error: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Version != "1.2.0" || releases[0].VersionPin != "^1.2" {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestFetchReleases_VCSRepository(t *testing.T) {
+	t.Parallel()
+	client := drupalupdate.NewClient()
+	client.PackagistBaseURL = ""
+	client.Repositories = drupalupdate.Repositories{
+		List: []drupalupdate.Repository{{Type: drupalupdate.RepositoryVCS, URL: "https://github.com/acme/theme.git"}},
+	}
+
+	if _, err := client.FetchReleases(t.Context(), "acme/theme"); err == nil {
+		t.Error("expected error for package served by a vcs repository")
+	}
+}
+
+func TestFetchReleases_OnlyFilter(t *testing.T) {
+	t.Parallel()
+	satis := newSatisServer(t)
+	defer satis.Close()
+
+	client := drupalupdate.NewClient()
+	client.Repositories = drupalupdate.Repositories{
+		PackagistDisabled: true,
+		List: []drupalupdate.Repository{{
+			Type: drupalupdate.RepositoryComposer,
+			URL:  satis.URL,
+			Only: []string{"other/*"},
+		}},
+	}
+
+	if _, err := client.FetchReleases(t.Context(), "acme/lib"); err == nil {
+		t.Error("expected error for package excluded by the only filter")
+	}
+}
@@ -1,11 +1,17 @@
 //spellchecker:words drupalupdate
 package drupalupdate
 
-//spellchecker:words encoding json http
+//spellchecker:words bytes context encoding json http strconv strings
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // =============================================================================
@@ -14,7 +20,9 @@ import (
 
 // parseRequest is the request body for POST /api/parse.
 type parseRequest struct {
-	ComposerJSON ComposerJSON `json:"composer_json"`
+	ComposerJSON ComposerJSON    `json:"composer_json"`
+	ComposerLock *ComposerLock   `json:"composer_lock,omitempty"` // optional, used to add installed versions
+	PatchesFile  json.RawMessage `json:"patches_file,omitempty"`  // optional contents of the external patches file
 }
 
 // ParseResponse is the response body for POST /api/parse.
@@ -28,14 +36,91 @@ type ParseResponse struct {
 type ReleasesResponse struct {
 	Package  string    `json:"package"`
 	Releases []Release `json:"releases"`
+
+	Project           string        `json:"project,omitempty"`            // drupal.org project of Drupal packages, e.g. the parent project of sub-modules
+	SecurityUpdate    string        `json:"security_update,omitempty"`    // newest security release the current constraint is below, if any
+	Advisories        []Advisory    `json:"advisories,omitempty"`         // security advisories affecting the installed version or current constraint
+	ProjectStatus     ProjectStatus `json:"project_status,omitempty"`     // status of the drupal.org project, if known
+	ProjectWarning    string        `json:"project_warning,omitempty"`    // warning about the project status, if any
+	BranchUnsupported bool          `json:"branch_unsupported,omitempty"` // the current constraint is not on a supported branch of the drupal.org project
+	Abandoned         bool          `json:"abandoned,omitempty"`          // the package is abandoned according to its composer metadata
+	Replacement       string        `json:"replacement,omitempty"`        // package suggested to replace the abandoned package, if any
+
+	Error string `json:"error,omitempty"` // error fetching the releases, only used by POST /api/releases
+}
+
+// ReleasesBatchRequest is the request body for POST /api/releases.
+type ReleasesBatchRequest struct {
+	Packages []Package `json:"packages"` // packages with their current constraint and installed version, if any
+}
+
+// ReleasesBatchResponse is the response body for POST /api/releases.
+type ReleasesBatchResponse struct {
+	Packages []ReleasesResponse `json:"packages"` // releases of each package, ordered like in the request
+}
+
+// ChangelogResponse is the response body for GET /api/changelog?package=...&from=...&to=...
+type ChangelogResponse struct {
+	Package  string         `json:"package"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Releases []ReleaseNotes `json:"releases"` // release notes, newest first
+}
+
+// AdvisoriesResponse is the response body for GET /api/advisories?package=...
+type AdvisoriesResponse struct {
+	Advisories map[string][]Advisory `json:"advisories"` // advisories per package, packages without advisories are omitted
 }
 
 // UpdateRequest is the request body for POST /api/update.
 type UpdateRequest struct {
-	ComposerJSON ComposerJSON      `json:"composer_json"`
-	Versions     map[string]string `json:"versions"` // package name -> new version
+	ComposerJSON ComposerJSON       `json:"composer_json"`
+	Versions     map[string]string  `json:"versions"`               // package name -> new version, applied to "require" and "require-dev" unless a section is given
+	Sections     map[string]Section `json:"sections,omitempty"`     // optional package name -> section, to update a package only in that section
+	PatchesFile  json.RawMessage    `json:"patches_file,omitempty"` // optional contents of the external patches file
+	Replacements map[string]string  `json:"replacements,omitempty"` // package name -> replacing package, required with its version from Versions or the pin of its newest release
+}
+
+// UnmarshalJSON implements json.Unmarshaler for UpdateRequest.
+// The composer.json may be given as a JSON object, or as a string holding the text of the file.
+// The latter keeps the formatting of the whole file, including the whitespace at its end.
+func (r *UpdateRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateRequest
+	var req struct {
+		plain
+		ComposerJSON json.RawMessage `json:"composer_json"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("failed to unmarshal update request: %w", err)
+	}
+	*r = UpdateRequest(req.plain)
+
+	if len(req.ComposerJSON) == 0 {
+		return nil
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(req.ComposerJSON), []byte{'"'}) {
+		if err := json.Unmarshal(req.ComposerJSON, &r.ComposerJSON); err != nil {
+			return fmt.Errorf("failed to unmarshal composer_json: %w", err)
+		}
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(req.ComposerJSON, &text); err != nil {
+		return fmt.Errorf("failed to unmarshal composer_json: %w", err)
+	}
+	composer, err := ParseComposerJSON([]byte(text))
+	if err != nil {
+		return err
+	}
+	r.ComposerJSON = composer
+	return nil
 }
 
+// PatchedPackagesHeader is the response header of POST /api/update listing the
+// comma-separated names of updated packages that carry patches.
+const PatchedPackagesHeader = "X-Patched-Packages"
+
 // ErrorResponse is returned on errors.
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -50,6 +135,10 @@ type Server struct {
 	Client *Client
 	Logger *log.Logger
 
+	// RepositoryPolicy restricts the custom repositories given in requests.
+	// If nil, composer repositories given in requests are ignored, see [RepositoryPolicy].
+	RepositoryPolicy *RepositoryPolicy
+
 	mux *http.ServeMux
 }
 
@@ -59,6 +148,10 @@ func NewServer(client *Client) *Server {
 	s.mux = http.NewServeMux()
 	s.mux.HandleFunc("POST /api/parse", s.handleParse)
 	s.mux.HandleFunc("GET /api/releases", s.handleReleases)
+	s.mux.HandleFunc("POST /api/releases", s.handleReleasesBatch)
+	s.mux.HandleFunc("GET /api/changelog", s.handleChangelog)
+	s.mux.HandleFunc("GET /api/stale", s.handleStale)
+	s.mux.HandleFunc("GET /api/advisories", s.handleAdvisories)
 	s.mux.HandleFunc("POST /api/update", s.handleUpdate)
 	s.Logger = log.Default()
 	return s
@@ -75,6 +168,8 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleParse accepts a composer.json and returns all updatable packages,
 // split into Drupal and Composer (non-Drupal) categories.
+// If a composer.lock is given, the installed version of each package is added.
+// Patches from composer-patches are added to each package.
 func (s *Server) handleParse(w http.ResponseWriter, r *http.Request) {
 	var req parseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -82,32 +177,285 @@ func (s *Server) handleParse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, ParseResponse{
+	resp := ParseResponse{
 		CorePackages:     req.ComposerJSON.CorePackages(),
 		DrupalPackages:   req.ComposerJSON.DrupalPackages(),
 		ComposerPackages: req.ComposerJSON.ComposerPackages(),
-	})
+	}
+	if req.ComposerLock != nil {
+		req.ComposerLock.Annotate(resp.CorePackages)
+		req.ComposerLock.Annotate(resp.DrupalPackages)
+		req.ComposerLock.Annotate(resp.ComposerPackages)
+	}
+
+	patches, err := readPatches(&req.ComposerJSON, req.PatchesFile)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	patches.Annotate(resp.CorePackages)
+	patches.Annotate(resp.DrupalPackages)
+	patches.Annotate(resp.ComposerPackages)
+
+	s.writeJSON(w, http.StatusOK, resp)
 }
 
-// handleReleases returns available releases for a given composer package.
-// For drupal/* packages it queries drupal.org; for others it queries Packagist.
+// handleReleases returns available releases for a given composer package,
+// from drupal.org for drupal/* packages and from Packagist or custom repositories otherwise.
+// The query parameters are documented in openapi.yaml.
 func (s *Server) handleReleases(w http.ResponseWriter, r *http.Request) {
-	pkg := r.URL.Query().Get("package")
-	if pkg == "" {
+	pkg := Package{Name: r.URL.Query().Get("package"), Version: r.URL.Query().Get("current"), Installed: r.URL.Query().Get("installed")}
+	if pkg.Name == "" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "missing 'package' query parameter"})
+		return
+	}
+
+	query, err := s.parseReleasesQuery(r.URL.Query())
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	found, err := query.client.ForPackage(pkg).FetchPackageReleases(r.Context(), pkg.Name)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: "failed to fetch releases: " + err.Error()})
+		return
+	}
+	advisories, err := query.client.FetchAdvisories(r.Context(), []string{pkg.Name})
+	if err != nil {
+		s.Logger.Printf("handleReleases: %s: %v", pkg.Name, err)
+	}
+	s.writeJSON(w, http.StatusOK, query.response(pkg, found, advisories[pkg.Name]))
+}
+
+// handleReleasesBatch returns the releases of several packages like handleReleases,
+// fetching them concurrently using [Client.FetchAll].
+// Packages are given in the request body with their current constraint and installed version,
+// all other options are given as query parameters like for handleReleases.
+// Failing to fetch a single package is reported in its Error.
+func (s *Server) handleReleasesBatch(w http.ResponseWriter, r *http.Request) {
+	var req ReleasesBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON: " + err.Error()})
+		return
+	}
+	if len(req.Packages) == 0 {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "missing packages"})
+		return
+	}
+	names := make([]string, len(req.Packages))
+	for i, pkg := range req.Packages {
+		if err := checkPackageName(pkg.Name); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid package name: " + err.Error()})
+			return
+		}
+		names[i] = pkg.Name
+	}
+
+	query, err := s.parseReleasesQuery(r.URL.Query())
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	advisories, err := query.client.FetchAdvisories(r.Context(), names)
+	if err != nil {
+		s.Logger.Printf("handleReleasesBatch: %v", err)
+	}
+	results := query.client.FetchAll(r.Context(), req.Packages)
+
+	resp := ReleasesBatchResponse{Packages: make([]ReleasesResponse, len(results))}
+	for i, result := range results {
+		if result.Err != nil {
+			resp.Packages[i] = ReleasesResponse{Package: result.Package.Name, Error: "failed to fetch releases: " + result.Err.Error()}
+			continue
+		}
+		resp.Packages[i] = query.response(result.Package, result.Releases, advisories[result.Package.Name])
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// releasesQuery holds the query parameters of the releases endpoints that apply to every package.
+type releasesQuery struct {
+	client *Client    // client using the repositories, stability settings, PHP version, pin strategy and mode
+	core   string     // targeted drupal core version, if any
+	update UpdateType // maximum update type, if any
+}
+
+// parseReleasesQuery reads the query parameters of the releases endpoints.
+func (s *Server) parseReleasesQuery(query url.Values) (releasesQuery, error) {
+	repos, err := ParseRepositories([]byte(query.Get("repositories")))
+	if err != nil {
+		return releasesQuery{}, fmt.Errorf("invalid 'repositories' query parameter: %w", err)
+	}
+
+	settings, err := parseStabilityQuery(query)
+	if err != nil {
+		return releasesQuery{}, err
+	}
+
+	strategy, err := ParsePinStrategy(query.Get("pin"))
+	if err != nil {
+		return releasesQuery{}, fmt.Errorf("invalid 'pin' query parameter: %w", err)
+	}
+
+	var update UpdateType
+	if limit := query.Get("max-update"); limit != "" {
+		update, err = ParseUpdateType(limit)
+		if err != nil {
+			return releasesQuery{}, fmt.Errorf("invalid 'max-update' query parameter: %w", err)
+		}
+	}
+
+	mode, err := ParseReleaseMode(query.Get("mode"))
+	if err != nil {
+		return releasesQuery{}, fmt.Errorf("invalid 'mode' query parameter: %w", err)
+	}
+	include, err := ParseStabilities(query.Get("include"))
+	if err != nil {
+		return releasesQuery{}, fmt.Errorf("invalid 'include' query parameter: %w", err)
+	}
+
+	client := s.withRepositories(repos).WithStability(settings).WithPlatformPHP(query.Get("php")).WithPinStrategy(strategy).WithMode(mode, include...)
+	return releasesQuery{client: client, core: query.Get("core"), update: update}, nil
+}
+
+// response returns the response for the releases found for pkg, marked relative to its current constraint and installed version.
+func (q releasesQuery) response(pkg Package, found PackageReleases, advisories []Advisory) ReleasesResponse {
+	releases := found.Releases
+	MarkInstalled(releases, pkg.Installed)
+	MarkAllowed(releases, pkg.Version)
+	MarkUpdateType(releases, pkg.Version)
+	MarkCoreCompatibility(releases, q.core)
+	MarkAdvisories(releases, advisories)
+	security := SecurityUpdate(releases, pkg.Version)
+	if q.update != "" {
+		releases = FilterUpdates(releases, q.update)
+	}
+
+	return ReleasesResponse{
+		Package:           pkg.Name,
+		Releases:          releases,
+		SecurityUpdate:    security,
+		Advisories:        CurrentAdvisories(advisories, pkg.Version, pkg.Installed),
+		Project:           found.Project,
+		ProjectStatus:     found.Status,
+		ProjectWarning:    found.Status.Warning(),
+		BranchUnsupported: BranchUnsupported(pkg.Version, found.SupportedBranches),
+		Abandoned:         found.Abandoned,
+		Replacement:       found.Replacement,
+	}
+}
+
+// handleChangelog returns the release notes of all releases of a package between two versions.
+// The lower bound is either the installed version or the current constraint.
+// Custom repositories and stability settings are honored like by handleReleases.
+// With "format=markdown", the release notes are returned as markdown instead of JSON.
+func (s *Server) handleChangelog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pkg, from, to := query.Get("package"), query.Get("from"), query.Get("to")
+	if pkg == "" || to == "" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "missing 'package' or 'to' query parameter"})
+		return
+	}
+	if v := ParseVersion(strings.TrimPrefix(to, "v")); v.Major < 0 || v.Minor == WildcardSegment {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid 'to' query parameter: " + errChangelogTarget.Error()})
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid 'format' query parameter: expected json or markdown"})
+		return
+	}
+
+	repos, err := ParseRepositories([]byte(query.Get("repositories")))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid 'repositories' query parameter: " + err.Error()})
+		return
+	}
+	settings, err := parseStabilityQuery(query)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	changelog, err := s.withRepositories(repos).WithStability(settings.ForConstraint(from)).FetchChangelog(r.Context(), pkg, from, to)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: "failed to fetch changelog: " + err.Error()})
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(FormatChangelog(changelog))); err != nil {
+			s.Logger.Printf("handleChangelog: write failed: %v", err)
+		}
+		return
+	}
+	s.writeJSON(w, http.StatusOK, ChangelogResponse{Package: pkg, From: from, To: to, Releases: changelog})
+}
+
+// handleStale reports whether the latest release of a package, or its current release, is older than a maximum age.
+// The current release is the installed version, or the newest release allowed by the current constraint.
+// The maximum age defaults to [DefaultMaxAge].
+func (s *Server) handleStale(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pkg := Package{Name: query.Get("package"), Version: query.Get("current"), Installed: query.Get("installed")}
+	if pkg.Name == "" {
 		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "missing 'package' query parameter"})
 		return
 	}
 
-	releases, err := s.Client.FetchReleases(r.Context(), pkg)
+	maxAge := DefaultMaxAge
+	if age := query.Get("max-age"); age != "" {
+		var err error
+		maxAge, err = ParseAge(age)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid 'max-age' query parameter: " + err.Error()})
+			return
+		}
+	}
+
+	repos, err := ParseRepositories([]byte(query.Get("repositories")))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid 'repositories' query parameter: " + err.Error()})
+		return
+	}
+
+	staleness, err := s.withRepositories(repos).FetchStaleness(r.Context(), pkg, maxAge)
 	if err != nil {
 		s.writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: "failed to fetch releases: " + err.Error()})
 		return
 	}
+	s.writeJSON(w, http.StatusOK, staleness)
+}
 
-	s.writeJSON(w, http.StatusOK, ReleasesResponse{Package: pkg, Releases: releases})
+// handleAdvisories returns the security advisories of one or more packages from Packagist.
+// Packages are given by repeating the "package" query parameter.
+func (s *Server) handleAdvisories(w http.ResponseWriter, r *http.Request) {
+	pkgs := r.URL.Query()["package"]
+	if len(pkgs) == 0 {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "missing 'package' query parameter"})
+		return
+	}
+	for _, pkg := range pkgs {
+		if err := checkPackageName(pkg); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid 'package' query parameter: " + err.Error()})
+			return
+		}
+	}
+
+	advisories, err := s.Client.FetchAdvisories(r.Context(), pkgs)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: "failed to fetch advisories: " + err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, AdvisoriesResponse{Advisories: advisories})
 }
 
 // handleUpdate accepts a composer.json and a version map, and returns the updated composer.json.
+// Updated packages that carry patches are listed in the [PatchedPackagesHeader] header.
 func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	var req UpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -115,19 +463,128 @@ func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for pkg, section := range req.Sections {
+		if _, ok := sectionByKey(string(section)); !ok {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid section %q for %s", section, pkg)})
+			return
+		}
+	}
+
+	patches, err := readPatches(&req.ComposerJSON, req.PatchesFile)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	replacementVersions := make(map[string]string, len(req.Replacements))
+	for pkg, replacement := range req.Replacements {
+		if version, ok := req.Versions[replacement]; ok {
+			replacementVersions[replacement] = version
+			continue
+		}
+		version, err := s.newestVersionPin(r.Context(), &req.ComposerJSON, replacement)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: fmt.Sprintf("failed to pin %s to replace %s: %v", replacement, pkg, err)})
+			return
+		}
+		replacementVersions[replacement] = version
+	}
+
+	if patched := patches.PatchedUpdates(&req.ComposerJSON, req.Versions); len(patched) > 0 {
+		w.Header().Set(PatchedPackagesHeader, strings.Join(patched, ", "))
+	}
+
 	for pkg, version := range req.Versions {
-		if _, exists := req.ComposerJSON.Require[pkg]; exists {
-			req.ComposerJSON.Require[pkg] = version
+		if section, ok := req.Sections[pkg]; ok {
+			req.ComposerJSON.SetVersion(section, pkg, version)
+			continue
 		}
+		req.ComposerJSON.UpdateVersion(pkg, version)
+	}
+	for pkg, replacement := range req.Replacements {
+		req.ComposerJSON.ReplacePackage(pkg, replacement, replacementVersions[replacement])
 	}
 
-	s.writeJSON(w, http.StatusOK, req.ComposerJSON)
+	// write the composer.json as is, since encoding it as part of another value would lose its formatting
+	output, err := req.ComposerJSON.MarshalJSON()
+	if err != nil {
+		s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(output); err != nil {
+		s.Logger.Printf("handleUpdate: write failed after headers sent: %v", err)
+	}
 }
 
 // =============================================================================
 // Helpers
 // =============================================================================
 
+// noRepositories is the RepositoryPolicy of servers without one, allowing no host.
+var noRepositories = NewRepositoryPolicy(nil, false)
+
+// withRepositories returns a copy of the client of s using the custom repositories given in a request.
+// They are restricted by the RepositoryPolicy of s, so that clients cannot make the server fetch from arbitrary URLs.
+func (s *Server) withRepositories(repos Repositories) *Client {
+	policy := s.RepositoryPolicy
+	if policy == nil {
+		policy = noRepositories
+	}
+	return s.Client.WithRepositories(repos).WithRepositoryPolicy(policy)
+}
+
+// newestVersionPin returns the version pin of the newest release of name,
+// fetched with the repositories and stability settings of composer.
+func (s *Server) newestVersionPin(ctx context.Context, composer *ComposerJSON, name string) (string, error) {
+	repos, err := composer.Repositories()
+	if err != nil {
+		return "", err
+	}
+	stability, err := composer.StabilitySettings()
+	if err != nil {
+		return "", err
+	}
+	releases, err := s.withRepositories(repos).WithStability(stability).FetchReleases(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if len(releases) == 0 {
+		return "", fmt.Errorf("no release of %s found", name)
+	}
+	return releases[0].VersionPin, nil
+}
+
+// readPatches returns the patches defined in composer.json, merged with those of the patches file if given.
+func readPatches(composer *ComposerJSON, patchesFile json.RawMessage) (Patches, error) {
+	patches, err := composer.Patches()
+	if err != nil {
+		return nil, err
+	}
+	if len(patchesFile) == 0 {
+		return patches, nil
+	}
+	external, err := ParsePatchesFile(patchesFile)
+	if err != nil {
+		return nil, err
+	}
+	return patches.Merge(external), nil
+}
+
+// parseStabilityQuery reads the "minimum-stability" and "prefer-stable" query parameters.
+func parseStabilityQuery(query url.Values) (StabilitySettings, error) {
+	settings := StabilitySettings{MinimumStability: normalizeStability(query.Get("minimum-stability"))}
+	if preferStable := query.Get("prefer-stable"); preferStable != "" {
+		value, err := strconv.ParseBool(preferStable)
+		if err != nil {
+			return settings, fmt.Errorf("invalid 'prefer-stable' query parameter: %w", err)
+		}
+		settings.PreferStable = value
+	}
+	return settings, nil
+}
+
 func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
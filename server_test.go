@@ -1,12 +1,18 @@
 //spellchecker:words drupalupdate
 package drupalupdate_test
 
-//spellchecker:words bytes encoding json http httptest testing github composer drupal update drupalupdate
+//spellchecker:words bytes context encoding json maps http httptest url slices strings sync atomic testing github composer drupal update drupalupdate
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
@@ -35,6 +41,17 @@ func newTestServer(t *testing.T) (*drupalupdate.Server, func()) {
 	}))
 
 	packagistMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/security-advisories/" {
+			w.Header().Set("Content-Type", "application/json")
+			body := `{"advisories": []}`
+			if slices.Contains(r.URL.Query()["packages[]"], "drush/drush") {
+				body = sampleAdvisoriesJSON
+			}
+			if _, err := w.Write([]byte(body)); err != nil {
+				return
+			}
+			return
+		}
 		if r.URL.Path == "/p2/drush/drush.json" {
 			w.Header().Set("Content-Type", "application/json")
 			if _, err := w.Write([]byte(samplePackagistJSON)); err != nil {
@@ -47,7 +64,9 @@ func newTestServer(t *testing.T) (*drupalupdate.Server, func()) {
 
 	client := drupalupdate.NewClient()
 	client.DrupalBaseURL = drupalMock.URL
+	client.DrupalComposerURL = drupalMock.URL
 	client.PackagistBaseURL = packagistMock.URL
+	client.PackagistAPIURL = packagistMock.URL
 
 	server := drupalupdate.NewServer(client)
 
@@ -149,6 +168,49 @@ func TestServer_Parse(t *testing.T) {
 	}
 }
 
+func TestServer_Parse_WithLock(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"require": {
+				"drupal/admin_toolbar": "^3.5",
+				"drupal/gin": "^5.0",
+				"drush/drush": "^12"
+			}
+		},
+		"composer_lock": ` + sampleLockJSON + `
+	}`
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/parse", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ParseResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.DrupalPackages) != 2 {
+		t.Fatalf("expected 2 drupal packages, got %d", len(resp.DrupalPackages))
+	}
+	if resp.DrupalPackages[0].Installed != "3.5.0" {
+		t.Errorf("expected admin_toolbar installed 3.5.0, got %q", resp.DrupalPackages[0].Installed)
+	}
+	if resp.DrupalPackages[1].Installed != "" {
+		t.Errorf("expected gin not to be installed, got %q", resp.DrupalPackages[1].Installed)
+	}
+	if len(resp.ComposerPackages) != 1 || resp.ComposerPackages[0].Installed != "12.5.6" {
+		t.Errorf("unexpected composer packages: %+v", resp.ComposerPackages)
+	}
+}
+
 func TestServer_Parse_InvalidJSON(t *testing.T) {
 	t.Parallel()
 	server, cleanup := newTestServer(t)
@@ -295,92 +357,1025 @@ func TestServer_Releases_Core(t *testing.T) {
 	}
 }
 
-func TestServer_Releases_MissingPackage(t *testing.T) {
+func TestServer_Releases_Installed(t *testing.T) {
 	t.Parallel()
 	server, cleanup := newTestServer(t)
 	defer cleanup()
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodGet, "/api/releases", nil)
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&installed=12.5.6", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []drupalupdate.ReleaseRelation{
+		drupalupdate.RelationNewer,
+		drupalupdate.RelationInstalled,
+		drupalupdate.RelationOlder,
+	}
+	if len(resp.Releases) != len(want) {
+		t.Fatalf("expected %d releases, got %d", len(want), len(resp.Releases))
+	}
+	for i, relation := range want {
+		if resp.Releases[i].Relation != relation {
+			t.Errorf("release %s: expected %q, got %q", resp.Releases[i].Version, relation, resp.Releases[i].Relation)
+		}
+	}
+}
+
+func TestServer_Releases_Current(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&current="+url.QueryEscape("^12.5"), nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []bool{false, true, false}
+	if len(resp.Releases) != len(want) {
+		t.Fatalf("expected %d releases, got %d", len(want), len(resp.Releases))
+	}
+	for i, allowed := range want {
+		if resp.Releases[i].Allowed != allowed {
+			t.Errorf("release %s: expected allowed = %v, got %v", resp.Releases[i].Version, allowed, resp.Releases[i].Allowed)
+		}
+	}
+}
+
+func TestServer_Releases_Update(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&current="+url.QueryEscape("^12.4")+"&max-update=minor", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]drupalupdate.UpdateType{"12.5.6": drupalupdate.UpdateMinor, "11.0.0": drupalupdate.UpdateNone}
+	if len(resp.Releases) != len(want) {
+		t.Fatalf("expected %d releases, got %d", len(want), len(resp.Releases))
+	}
+	for _, release := range resp.Releases {
+		if release.Update != want[release.Version] {
+			t.Errorf("release %s: expected update %q, got %q", release.Version, want[release.Version], release.Update)
+		}
+	}
+}
+
+func TestServer_Releases_InvalidMaxUpdate(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&max-update=huge", nil)
 	server.ServeHTTP(w, r)
 
 	if w.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d", w.Code)
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestServer_Releases_NotFound(t *testing.T) {
+func TestServer_Releases_SecurityUpdate(t *testing.T) {
 	t.Parallel()
 	server, cleanup := newTestServer(t)
 	defer cleanup()
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/nonexistent", nil)
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/admin_toolbar&current="+url.QueryEscape("^4.0"), nil)
 	server.ServeHTTP(w, r)
 
-	if w.Code != http.StatusBadGateway {
-		t.Fatalf("expected 502, got %d", w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.SecurityUpdate != "4.0.1" {
+		t.Errorf("expected security update 4.0.1, got %q", resp.SecurityUpdate)
 	}
 }
 
-// =============================================================================
-// POST /api/update
-// =============================================================================
+func TestServer_Releases_ProjectStatus(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
 
-func TestServer_Update(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/admin_toolbar&current="+url.QueryEscape("^2.0"), nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProjectStatus != drupalupdate.ProjectPublished || resp.ProjectWarning != "" {
+		t.Errorf("expected published project without warning, got %q (%q)", resp.ProjectStatus, resp.ProjectWarning)
+	}
+	if !resp.BranchUnsupported {
+		t.Error("expected ^2.0 to be on an unsupported branch")
+	}
+}
+
+func TestServer_Releases_Core_Target(t *testing.T) {
 	t.Parallel()
 	server, cleanup := newTestServer(t)
 	defer cleanup()
 
-	body := `{
-		"composer_json": {
-			"name": "my/project",
-			"require": {
-				"drupal/admin_toolbar": "^3.6",
-				"drupal/gin": "^5.0",
-				"drush/drush": "^12"
-			},
-			"extra": {"key": "value"}
-		},
-		"versions": {
-			"drupal/admin_toolbar": "^4.0",
-			"drush/drush": "^13"
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/admin_toolbar&core=11.1.0", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"4.0.2": false, "3.0.5": true}
+	if len(resp.Releases) != len(want) {
+		t.Fatalf("expected %d releases, got %d", len(want), len(resp.Releases))
+	}
+	for _, release := range resp.Releases {
+		if release.CoreIncompatible != want[release.Version] {
+			t.Errorf("release %s: expected core incompatible = %v, got %v", release.Version, want[release.Version], release.CoreIncompatible)
 		}
-	}`
+	}
+}
+
+func TestServer_Releases_Pin(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "^13.0"},
+		{"&pin=exact", "13.0.1"},
+		{"&pin=keep&current=" + url.QueryEscape("~12.4.0"), "~13.0.1"},
+		{"&pin=keep&current=" + url.QueryEscape("^12"), "^13.0"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush"+tt.query, nil)
+		server.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+
+		var resp drupalupdate.ReleasesResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Releases) == 0 {
+			t.Fatalf("%s: expected releases", tt.query)
+		}
+		if resp.Releases[0].VersionPin != tt.want {
+			t.Errorf("%s: expected version pin %q, got %q", tt.query, tt.want, resp.Releases[0].VersionPin)
+		}
+	}
+}
+
+func TestServer_Releases_InvalidPin(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
-	r.Header.Set("Content-Type", "application/json")
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&pin=loose", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_Releases_ModeAll(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&mode=all&include=rc", nil)
 	server.ServeHTTP(w, r)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	// Parse the returned composer.json to verify changes
-	var result drupalupdate.ComposerJSON
-	err := json.Unmarshal(w.Body.Bytes(), &result)
-	if err != nil {
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatal(err)
 	}
+	var pins []string
+	for _, release := range resp.Releases {
+		pins = append(pins, release.VersionPin)
+	}
+	want := []string{"^13.0.1", "^13.0.0", "^13.0.0@RC", "^12.5.6", "^12.4.0", "^11.0.0"}
+	if !slices.Equal(pins, want) {
+		t.Errorf("expected pins %v, got %v", want, pins)
+	}
+}
 
-	if result.Require["drupal/admin_toolbar"] != "^4.0" {
-		t.Errorf("expected ^4.0, got %s", result.Require["drupal/admin_toolbar"])
+func TestServer_Releases_InvalidMode(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	for _, query := range []string{"mode=every", "mode=all&include=nightly"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&"+query, nil)
+		server.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", query, w.Code, w.Body.String())
+		}
 	}
-	if result.Require["drush/drush"] != "^13" {
-		t.Errorf("expected ^13, got %s", result.Require["drush/drush"])
+}
+
+func TestServer_Releases_InvalidPreferStable(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&minimum-stability=RC&prefer-stable=maybe", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
 	}
-	// gin should remain unchanged
-	if result.Require["drupal/gin"] != "^5.0" {
-		t.Errorf("expected ^5.0, got %s", result.Require["drupal/gin"])
+}
+
+func TestServer_Releases_Repositories(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	repos := url.QueryEscape(`[{"packagist.org": false}]`)
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&repositories="+repos, nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 with packagist.org disabled, got %d: %s", w.Code, w.Body.String())
 	}
 
-	// Verify extra fields are preserved
-	if _, ok := result.Raw["extra"]; !ok {
-		t.Error("extra field was lost")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&repositories=notjson", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid repositories, got %d: %s", w.Code, w.Body.String())
 	}
-	if _, ok := result.Raw["name"]; !ok {
-		t.Error("name field was lost")
+}
+
+func TestServer_Releases_RepositoryPolicy(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	satis := newSatisServer(t)
+	defer satis.Close()
+
+	repos := url.QueryEscape(`[{"type": "composer", "url": "` + satis.URL + `"}, {"packagist.org": false}]`)
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/releases?package=acme/lib&repositories="+repos, nil)
+		server.ServeHTTP(w, r)
+		return w
+	}
+
+	// without a policy, repositories given in requests are ignored
+	if w := request(); w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 without a repository policy, got %d: %s", w.Code, w.Body.String())
+	}
+
+	server.RepositoryPolicy = drupalupdate.NewRepositoryPolicy([]string{"127.0.0.1"}, true)
+	if w := request(); w.Code != http.StatusOK {
+		t.Errorf("expected 200 with the repository host allowed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_Releases_RepositoryPolicy_DrupalComposer(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		policy *drupalupdate.RepositoryPolicy
+	}{
+		{name: "default policy"},
+		{name: "other host", policy: drupalupdate.NewRepositoryPolicy([]string{"satis.example.com"}, false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mock := newDrupalComposerServer(t, true)
+
+			client := drupalupdate.NewClient().WithDrupalSource(drupalupdate.DrupalSourceComposer)
+			client.DrupalBaseURL = mock.URL
+			client.DrupalComposerURL = mock.URL + "/8"
+			client.PackagistBaseURL = ""
+			client.PackagistAPIURL = ""
+			server := drupalupdate.NewServer(client)
+			server.RepositoryPolicy = tt.policy
+
+			// the drupal.org composer repository of the server is not restricted by the policy
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/admin_toolbar&php=8.1", nil)
+			server.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp drupalupdate.ReleasesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Releases) == 0 || resp.Releases[0].Version != "3.5.0" || resp.Releases[0].PHP != ">=8.1" {
+				t.Errorf("expected releases from the drupal.org composer repository, got %+v", resp.Releases)
+			}
+		})
+	}
+}
+
+func TestServer_SubModule(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	mock := newCountingServer(t, newProjectServer(t).Config.Handler, &requests)
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = mock.URL
+	client.DrupalComposerURL = mock.URL + "/8"
+	client.PackagistBaseURL = ""
+	client.PackagistAPIURL = ""
+	server := drupalupdate.NewServer(client)
+
+	// parsing does not fetch anything
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/parse", bytes.NewBufferString(`{"composer_json": {"require": {"drupal/admin_toolbar_tools": "^3.5"}}}`))
+	server.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var parsed drupalupdate.ParseResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.DrupalPackages) != 1 || parsed.DrupalPackages[0].Module != "admin_toolbar_tools" {
+		t.Errorf("expected the module name of the sub-module, got %+v", parsed.DrupalPackages)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("expected parsing not to fetch anything, got %d requests", got)
+	}
+
+	// the project is resolved when fetching the releases
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/admin_toolbar_tools", nil)
+	server.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Project != "admin_toolbar" || len(resp.Releases) == 0 {
+		t.Errorf("expected the releases of admin_toolbar, got project %q and %+v", resp.Project, resp.Releases)
+	}
+}
+
+func TestServer_Parse_Patches(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"require": {"drupal/gin": "^3.0", "drush/drush": "^12"},
+			"extra": {"patches": {"drupal/gin": {"Fix toolbar": "gin.patch"}}}
+		},
+		"patches_file": {"patches": {"drush/drush": {"Fix alias": "drush.patch"}}}
+	}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/parse", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ParseResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.DrupalPackages) != 1 || len(resp.DrupalPackages[0].Patches) != 1 {
+		t.Errorf("expected drupal/gin to carry one patch, got %+v", resp.DrupalPackages)
+	}
+	if len(resp.ComposerPackages) != 1 || len(resp.ComposerPackages[0].Patches) != 1 {
+		t.Errorf("expected drush/drush to carry one patch from the patches file, got %+v", resp.ComposerPackages)
+	}
+}
+
+func TestServer_Update_PatchedPackages(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"require": {"drupal/gin": "^3.0", "drupal/webform": "^6.2"},
+			"extra": {"patches": {"drupal/gin": {"Fix toolbar": "gin.patch"}}}
+		},
+		"versions": {"drupal/gin": "^4.0", "drupal/webform": "^6.3"}
+	}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(drupalupdate.PatchedPackagesHeader); got != "drupal/gin" {
+		t.Errorf("expected patched packages header %q, got %q", "drupal/gin", got)
+	}
+}
+
+func TestServer_Releases_MissingPackage(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestServer_Releases_NotFound(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drupal/nonexistent", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", w.Code)
+	}
+}
+
+// =============================================================================
+// POST /api/releases
+// =============================================================================
+
+func TestServer_ReleasesBatch(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{"packages": [
+		{"name": "drupal/admin_toolbar", "version": "^3.0"},
+		{"name": "drupal/nonexistent", "version": "^1.0"},
+		{"name": "drush/drush", "version": "^13.0", "installed": "13.0.0"},
+		{"name": "drupal/core-recommended", "version": "^11.0"}
+	]}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/releases?max-update=minor", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ReleasesBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"drupal/admin_toolbar", "drupal/nonexistent", "drush/drush", "drupal/core-recommended"}
+	if len(resp.Packages) != len(want) {
+		t.Fatalf("expected %d packages, got %d", len(want), len(resp.Packages))
+	}
+	for i, name := range want {
+		if resp.Packages[i].Package != name {
+			t.Errorf("package %d: expected %s, got %s", i, name, resp.Packages[i].Package)
+		}
+	}
+
+	if admin := resp.Packages[0]; admin.Error != "" || len(admin.Releases) != 1 || admin.Releases[0].Version != "3.0.5" {
+		t.Errorf("expected only the 3.0.5 release of drupal/admin_toolbar, got %+v", admin)
+	}
+	if missing := resp.Packages[1]; missing.Error == "" || len(missing.Releases) != 0 {
+		t.Errorf("expected an error for drupal/nonexistent, got %+v", missing)
+	}
+	if drush := resp.Packages[2]; drush.Error != "" || len(drush.Advisories) != 1 {
+		t.Errorf("expected an advisory for drush/drush, got %+v", drush)
+	}
+	if core := resp.Packages[3]; core.Error != "" || len(core.Releases) == 0 || core.Releases[0].Version != "11.1.0" {
+		t.Errorf("expected drupal core releases for drupal/core-recommended, got %+v", core)
+	}
+}
+
+func TestServer_ReleasesBatch_InvalidRequest(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name  string
+		query string
+		body  string
+	}{
+		{name: "invalid JSON", body: `not json`},
+		{name: "no packages", body: `{"packages": []}`},
+		{name: "invalid package name", body: `{"packages": [{"name": "../etc/passwd"}]}`},
+		{name: "invalid pin", query: "?pin=bogus", body: `{"packages": [{"name": "drush/drush"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/releases"+tt.query, bytes.NewBufferString(tt.body))
+			server.ServeHTTP(w, r)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// =============================================================================
+// GET /api/changelog
+// =============================================================================
+
+func TestServer_Changelog(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	server.Client = server.Client.WithNotesFetcher(drupalupdate.NotesFetcherFunc(func(_ context.Context, _ string, release drupalupdate.Release) (string, error) {
+		return "Notes for " + release.Version, nil
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/changelog?package=drush/drush&from="+url.QueryEscape("^12.5")+"&to=13.0.1", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp drupalupdate.ChangelogResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []drupalupdate.ReleaseNotes{
+		{Version: "13.0.1", Notes: "Notes for 13.0.1"},
+		{Version: "13.0.0", Notes: "Notes for 13.0.0"},
+		{Version: "12.5.6", Notes: "Notes for 12.5.6"},
+	}
+	if !slices.Equal(resp.Releases, want) {
+		t.Errorf("expected releases %+v, got %+v", want, resp.Releases)
+	}
+}
+
+func TestServer_Changelog_Markdown(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+	server.Client = server.Client.WithNotesFetcher(drupalupdate.NotesFetcherFunc(func(_ context.Context, _ string, release drupalupdate.Release) (string, error) {
+		return "Notes for " + release.Version, nil
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/changelog?package=drupal/admin_toolbar&from=4.0.1&to=4.0.2&format=markdown", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/markdown") {
+		t.Errorf("expected markdown content type, got %q", ct)
+	}
+	if got, want := w.Body.String(), "## 4.0.2\n\nNotes for 4.0.2\n"; got != want {
+		t.Errorf("expected body %q, got %q", want, got)
+	}
+}
+
+func TestServer_Changelog_InvalidParameters(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	for _, query := range []string{
+		"package=drupal/admin_toolbar",
+		"to=4.0.2",
+		"package=drupal/admin_toolbar&to=dev-main",
+		"package=drupal/admin_toolbar&to=4.0.2&format=html",
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/changelog?"+query, nil)
+		server.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+// =============================================================================
+// GET /api/stale
+// =============================================================================
+
+func TestServer_Stale(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		maxAge       string
+		stale        bool
+		currentStale bool
+	}{
+		{"100y", false, false},
+		{"1d", true, true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/stale?package=drupal/admin_toolbar&installed=3.0.5&max-age="+tt.maxAge, nil)
+		server.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var resp drupalupdate.Staleness
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Latest != "4.0.2" || resp.Current != "3.0.5" {
+			t.Errorf("%s: expected latest 4.0.2 and current 3.0.5, got %q and %q", tt.maxAge, resp.Latest, resp.Current)
+		}
+		if resp.Stale != tt.stale || resp.CurrentStale != tt.currentStale {
+			t.Errorf("%s: expected stale %v and current stale %v, got %v and %v", tt.maxAge, tt.stale, tt.currentStale, resp.Stale, resp.CurrentStale)
+		}
+	}
+}
+
+func TestServer_Stale_InvalidParameters(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	for _, query := range []string{"max-age=1y", "package=drupal/admin_toolbar&max-age=soon"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/stale?"+query, nil)
+		server.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+// =============================================================================
+// POST /api/update
+// =============================================================================
+
+func TestServer_Releases_Advisories(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/releases?package=drush/drush&current="+url.QueryEscape("^12.4"), nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp drupalupdate.ReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Advisories) != 1 || resp.Advisories[0].CVE != "CVE-2024-12345" {
+		t.Errorf("expected the advisory of the current constraint, got %+v", resp.Advisories)
+	}
+	for _, release := range resp.Releases {
+		if len(release.Advisories) != 0 {
+			t.Errorf("release %s: expected no advisories, got %+v", release.Version, release.Advisories)
+		}
+	}
+}
+
+func TestServer_Advisories(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/advisories?package=drush/drush&package=drupal/gin", nil)
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp drupalupdate.AdvisoriesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Advisories) != 1 || len(resp.Advisories["drush/drush"]) != 1 {
+		t.Errorf("expected a single advisory for drush/drush, got %+v", resp.Advisories)
+	}
+}
+
+func TestServer_Advisories_InvalidParameters(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	for _, query := range []string{"", "package=invalid"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/advisories?"+query, nil)
+		server.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestServer_Update(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"name": "my/project",
+			"require": {
+				"drupal/admin_toolbar": "^3.6",
+				"drupal/gin": "^5.0",
+				"drush/drush": "^12"
+			},
+			"extra": {"key": "value"}
+		},
+		"versions": {
+			"drupal/admin_toolbar": "^4.0",
+			"drush/drush": "^13"
+		}
+	}`
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Parse the returned composer.json to verify changes
+	var result drupalupdate.ComposerJSON
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Require["drupal/admin_toolbar"] != "^4.0" {
+		t.Errorf("expected ^4.0, got %s", result.Require["drupal/admin_toolbar"])
+	}
+	if result.Require["drush/drush"] != "^13" {
+		t.Errorf("expected ^13, got %s", result.Require["drush/drush"])
+	}
+	// gin should remain unchanged
+	if result.Require["drupal/gin"] != "^5.0" {
+		t.Errorf("expected ^5.0, got %s", result.Require["drupal/gin"])
+	}
+
+	// Verify extra fields are preserved
+	if _, ok := result.Raw["extra"]; !ok {
+		t.Error("extra field was lost")
+	}
+	if _, ok := result.Raw["name"]; !ok {
+		t.Error("name field was lost")
+	}
+}
+
+func TestServer_Update_RequireDev(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"require": {
+				"drupal/gin": "^5.0"
+			},
+			"require-dev": {
+				"drush/drush": "^12"
+			}
+		},
+		"versions": {
+			"drush/drush": "^13"
+		}
+	}`
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result drupalupdate.ComposerJSON
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.RequireDev["drush/drush"] != "^13" {
+		t.Errorf("expected ^13, got %s", result.RequireDev["drush/drush"])
+	}
+	if _, exists := result.Require["drush/drush"]; exists {
+		t.Error("drush/drush should not be added to require")
+	}
+}
+
+func TestServer_Update_Text(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	input := "{\n" +
+		"  \"name\": \"drupal/example\",\n" +
+		"  \"require\": {\n" +
+		"    \"drush/drush\": \"^12\",\n" +
+		"    \"drupal/gin\": \"^5.0\"\n" +
+		"  },\n" +
+		"  \"extra\": {\"z\": \"<b>&</b>\", \"a\": []}\n" +
+		"}\n\n"
+	body, err := json.Marshal(map[string]any{
+		"composer_json": input,
+		"versions":      map[string]string{"drupal/gin": "^6.0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBuffer(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// only the changed constraint differs, including key order, spacing, escaping and the end of the file
+	want := strings.Replace(input, `"^5.0"`, `"^6.0"`, 1)
+	if got := w.Body.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestServer_Update_Sections(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"require": {"drush/drush": "^12", "drupal/gin": "^5.0"},
+			"require-dev": {"drush/drush": "^12", "drupal/gin": "^5.0"}
+		},
+		"versions": {"drush/drush": "^13", "drupal/gin": "^6.0"},
+		"sections": {"drush/drush": "require-dev"}
+	}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result drupalupdate.ComposerJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	// drush/drush is only updated in the given section, drupal/gin in both
+	if want := map[string]string{"drush/drush": "^12", "drupal/gin": "^6.0"}; !maps.Equal(result.Require, want) {
+		t.Errorf("expected require %v, got %v", want, result.Require)
+	}
+	if want := map[string]string{"drush/drush": "^13", "drupal/gin": "^6.0"}; !maps.Equal(result.RequireDev, want) {
+		t.Errorf("expected require-dev %v, got %v", want, result.RequireDev)
+	}
+}
+
+func TestServer_Update_InvalidSection(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{"composer_json": {"require": {"drush/drush": "^12"}}, "versions": {"drush/drush": "^13"}, "sections": {"drush/drush": "conflict"}}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_Update_Replacements(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {
+			"require": {"acme/old": "^1.0", "acme/gone": "^2.0", "drupal/gin": "^5.0"}
+		},
+		"versions": {"acme/new": "^3.0"},
+		"replacements": {"acme/old": "acme/new", "acme/gone": "drush/drush"}
+	}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result drupalupdate.ComposerJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"acme/new": "^3.0", "drush/drush": "^13.0", "drupal/gin": "^5.0"}
+	if !maps.Equal(result.Require, want) {
+		t.Errorf("expected require %v, got %v", want, result.Require)
+	}
+}
+
+func TestServer_Update_Replacements_NoRelease(t *testing.T) {
+	t.Parallel()
+	server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	body := `{
+		"composer_json": {"require": {"acme/gone": "^2.0"}},
+		"versions": {},
+		"replacements": {"acme/gone": "acme/other"}
+	}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/update", bytes.NewBufferString(body))
+	server.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
@@ -0,0 +1,77 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words encoding json
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StabilitySettings control which releases are offered, like the "minimum-stability"
+// and "prefer-stable" settings of a composer.json.
+// The zero value only offers stable releases.
+type StabilitySettings struct {
+	MinimumStability string // least stable stability offered, one of the Stability constants
+	PreferStable     bool   // offer a stable release of a branch over newer, less stable ones
+}
+
+// StabilitySettings returns the "minimum-stability" and "prefer-stable" settings of c.
+// Unknown stabilities are treated as stable, like composer does.
+func (c *ComposerJSON) StabilitySettings() (StabilitySettings, error) {
+	var settings StabilitySettings
+	if raw, ok := c.Raw["minimum-stability"]; ok {
+		var minimum string
+		if err := json.Unmarshal(raw, &minimum); err != nil {
+			return settings, fmt.Errorf("minimum-stability: %w", err)
+		}
+		settings.MinimumStability = normalizeStability(minimum)
+	}
+	if raw, ok := c.Raw["prefer-stable"]; ok {
+		if err := json.Unmarshal(raw, &settings.PreferStable); err != nil {
+			return settings, fmt.Errorf("prefer-stable: %w", err)
+		}
+	}
+	return settings, nil
+}
+
+// ForConstraint returns the settings that apply to a package with the given constraint.
+// An inline stability flag (e.g. "^1.0@beta") overrides the minimum stability for that package.
+func (s StabilitySettings) ForConstraint(constraint string) StabilitySettings {
+	c, err := ParseConstraint(constraint)
+	if err != nil || c.Stability == "" {
+		return s
+	}
+	s.MinimumStability = c.Stability
+	return s
+}
+
+// allows reports whether releases with the given stability may be offered.
+func (s StabilitySettings) allows(stability string) bool {
+	return stabilityRank(stability) >= stabilityRank(s.MinimumStability)
+}
+
+// versionStability returns the stability of a raw version string, e.g. "RC" for "8.x-1.0-rc3"
+// or "dev" for "dev-main" and "1.x-dev".
+// Versions that cannot be parsed are considered stable.
+func versionStability(version string) string {
+	return ParseVersion(version).Stability
+}
+
+// pickRelease returns the index of the release offered from candidates of a single branch.
+// Candidates are ordered newest first and described by their stability.
+// It returns -1 if no candidate is allowed.
+func (s StabilitySettings) pickRelease(stabilities []string) int {
+	picked := -1
+	for i, stability := range stabilities {
+		if !s.allows(stability) {
+			continue
+		}
+		if !s.PreferStable || stability == StabilityStable {
+			return i
+		}
+		if picked < 0 {
+			picked = i
+		}
+	}
+	return picked
+}
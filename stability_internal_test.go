@@ -0,0 +1,29 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words testing
+import "testing"
+
+func TestVersionStability(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"1.0.0", StabilityStable},
+		{"8.x-1.0-rc3", StabilityRC},
+		{"2.0.0-BETA2", StabilityBeta},
+		{"v1.2.0-alpha.1", StabilityAlpha},
+		{"1.x-dev", StabilityDev},
+		{"dev-main", StabilityDev},
+		// stability names outside the stability suffix
+		{"1.0.0+devtools", StabilityStable},
+		{"1.0.0-patch-beta-fix", StabilityStable},
+		{"1.0.0-rcs", StabilityStable},
+	}
+	for _, tt := range tests {
+		if got := versionStability(tt.version); got != tt.want {
+			t.Errorf("versionStability(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
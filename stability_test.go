@@ -0,0 +1,104 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words http httptest testing github composer drupal update drupalupdate
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestComposerJSON_StabilitySettings(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		json string
+		want drupalupdate.StabilitySettings
+	}{
+		{"default", `{"require": {}}`, drupalupdate.StabilitySettings{}},
+		{"RC", `{"minimum-stability": "rc"}`, drupalupdate.StabilitySettings{MinimumStability: drupalupdate.StabilityRC}},
+		{"dev, prefer stable", `{"minimum-stability": "dev", "prefer-stable": true}`, drupalupdate.StabilitySettings{MinimumStability: drupalupdate.StabilityDev, PreferStable: true}},
+		{"stable", `{"minimum-stability": "stable"}`, drupalupdate.StabilitySettings{}},
+	}
+	for _, tt := range tests {
+		composer, err := drupalupdate.ParseComposerJSON([]byte(tt.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := composer.StabilitySettings()
+		if err != nil {
+			t.Fatalf("%s: StabilitySettings returned error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: StabilitySettings() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComposerJSON_StabilitySettings_Invalid(t *testing.T) {
+	t.Parallel()
+	composer, err := drupalupdate.ParseComposerJSON([]byte(`{"prefer-stable": "yes"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := composer.StabilitySettings(); err == nil {
+		t.Error("expected error for invalid prefer-stable")
+	}
+}
+
+func TestStabilitySettings_ForConstraint(t *testing.T) {
+	t.Parallel()
+	settings := drupalupdate.StabilitySettings{MinimumStability: drupalupdate.StabilityRC, PreferStable: true}
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0", drupalupdate.StabilityRC},
+		{"^1.0@beta", drupalupdate.StabilityBeta},
+		{"1.x-dev@dev", drupalupdate.StabilityDev},
+		{"not a constraint", drupalupdate.StabilityRC},
+	}
+	for _, tt := range tests {
+		got := settings.ForConstraint(tt.constraint)
+		if got.MinimumStability != tt.want || !got.PreferStable {
+			t.Errorf("ForConstraint(%q) = %+v, want minimum stability %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestFetchReleases_MinimumStability(t *testing.T) {
+	t.Parallel()
+	drupalServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+			<project>
+				<supported_branches>2.0.</supported_branches>
+				<releases>
+					<release><name>rc_only 2.0.0-rc3</name><version>2.0.0-rc3</version><status>published</status></release>
+				</releases>
+			</project>`)); err != nil {
+			return
+		}
+	}))
+	defer drupalServer.Close()
+
+	client := drupalupdate.NewClient()
+	client.DrupalBaseURL = drupalServer.URL
+
+	releases, err := client.FetchReleases(t.Context(), "drupal/rc_only")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != 0 {
+		t.Errorf("expected no stable releases, got %+v", releases)
+	}
+
+	releases, err = client.WithStability(drupalupdate.StabilitySettings{MinimumStability: drupalupdate.StabilityRC}).FetchReleases(t.Context(), "drupal/rc_only")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != 1 || releases[0].VersionPin != "^2.0@RC" {
+		t.Errorf("expected the release candidate, got %+v", releases)
+	}
+}
@@ -0,0 +1,103 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words context errors strconv strings time
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAge is the default age after which a package without newer releases is considered stale.
+const DefaultMaxAge = 365 * 24 * time.Hour
+
+var errAgeInvalid = errors.New("invalid age")
+
+// ParseAge parses an age like "90d", "26w" or "2y", where a year has 365 days.
+// Other durations are parsed using [time.ParseDuration], e.g. "720h".
+func ParseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if number, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: %q", errAgeInvalid, s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("%w: %q", errAgeInvalid, s)
+	}
+	return age, nil
+}
+
+// Staleness describes how long ago a package was last released, and when its current release was published.
+type Staleness struct {
+	Package string `json:"package"`
+
+	Latest     string    `json:"latest,omitempty"`     // version of the most recently published release, if any has a date
+	LatestDate time.Time `json:"latest_date,omitzero"` // publication date of Latest
+	Stale      bool      `json:"stale,omitempty"`      // Latest is older than the maximum age, the package may be unmaintained
+
+	Current      string    `json:"current,omitempty"`       // version of the installed release, or the newest release allowed by the current constraint
+	CurrentDate  time.Time `json:"current_date,omitzero"`   // publication date of Current, if known
+	CurrentStale bool      `json:"current_stale,omitempty"` // Current is older than the maximum age
+}
+
+// CheckStaleness reports whether the latest release of pkg, or its current release, is older than maxAge at now.
+// The current release is the installed version of pkg, or the newest release allowed by its constraint.
+// Releases without a date are ignored, and releases are assumed to be ordered newest first.
+func CheckStaleness(pkg Package, releases []Release, maxAge time.Duration, now time.Time) Staleness {
+	staleness := Staleness{Package: pkg.Name}
+	for _, release := range releases {
+		if release.Date.After(staleness.LatestDate) {
+			staleness.Latest, staleness.LatestDate = release.Version, release.Date
+		}
+	}
+	if current, ok := currentRelease(releases, pkg); ok {
+		staleness.Current, staleness.CurrentDate = current.Version, current.Date
+	}
+
+	cutoff := now.Add(-maxAge)
+	staleness.Stale = !staleness.LatestDate.IsZero() && staleness.LatestDate.Before(cutoff)
+	staleness.CurrentStale = !staleness.CurrentDate.IsZero() && staleness.CurrentDate.Before(cutoff)
+	return staleness
+}
+
+// currentRelease returns the release of the installed version of pkg,
+// or the newest of releases allowed by its constraint if it is not installed.
+func currentRelease(releases []Release, pkg Package) (Release, bool) {
+	if installed := ParseVersion(strings.TrimPrefix(pkg.Installed, "v")); installed.Major >= 0 {
+		for _, release := range releases {
+			if v := ParseVersion(release.Version); v.Major >= 0 && v.compareRelease(installed) == 0 {
+				return release, true
+			}
+		}
+		return Release{}, false
+	}
+
+	c, err := ParseConstraint(pkg.Version)
+	if err != nil {
+		return Release{}, false
+	}
+	for _, release := range releases {
+		if c.Matches(ParseVersion(release.Version)) {
+			return release, true
+		}
+	}
+	return Release{}, false
+}
+
+// FetchStaleness fetches all releases of pkg, including pre-releases, and checks its staleness using [CheckStaleness].
+func (c *Client) FetchStaleness(ctx context.Context, pkg Package, maxAge time.Duration) (Staleness, error) {
+	releases, err := c.WithMode(ModeAll, StabilityRC, StabilityBeta, StabilityAlpha).FetchReleases(ctx, pkg.Name)
+	if err != nil {
+		return Staleness{Package: pkg.Name}, err
+	}
+	return CheckStaleness(pkg, releases, maxAge, time.Now()), nil
+}
@@ -0,0 +1,101 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words testing time github composer drupal update drupalupdate
+import (
+	"testing"
+	"time"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1y", 365 * 24 * time.Hour, false},
+		{"720h", 720 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1y", 0, true},
+		{"d", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := drupalupdate.ParseAge(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAge(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckStaleness(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	releases := []drupalupdate.Release{
+		{Version: "4.0.2", Date: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{Version: "4.0.1", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Version: "3.0.5", Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)}, // backported fix
+		{Version: "3.0.4"},
+		{Version: "3.0.0", Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name   string
+		pkg    drupalupdate.Package
+		maxAge time.Duration
+		want   drupalupdate.Staleness
+	}{
+		{
+			name:   "installed",
+			pkg:    drupalupdate.Package{Name: "drupal/a", Version: "^4.0", Installed: "4.0.1"},
+			maxAge: drupalupdate.DefaultMaxAge,
+			want: drupalupdate.Staleness{
+				Package: "drupal/a", Latest: "3.0.5", LatestDate: releases[2].Date,
+				Current: "4.0.1", CurrentDate: releases[1].Date, CurrentStale: true,
+			},
+		},
+		{
+			name:   "constraint",
+			pkg:    drupalupdate.Package{Name: "drupal/a", Version: "~3.0.0"},
+			maxAge: 90 * 24 * time.Hour,
+			want: drupalupdate.Staleness{
+				Package: "drupal/a", Latest: "3.0.5", LatestDate: releases[2].Date, Stale: true,
+				Current: "3.0.5", CurrentDate: releases[2].Date, CurrentStale: true,
+			},
+		},
+		{
+			name:   "current without date",
+			pkg:    drupalupdate.Package{Name: "drupal/a", Version: "^3.0", Installed: "3.0.4"},
+			maxAge: drupalupdate.DefaultMaxAge,
+			want: drupalupdate.Staleness{
+				Package: "drupal/a", Latest: "3.0.5", LatestDate: releases[2].Date,
+				Current: "3.0.4",
+			},
+		},
+		{
+			name:   "not found",
+			pkg:    drupalupdate.Package{Name: "drupal/a", Version: "^5.0"},
+			maxAge: drupalupdate.DefaultMaxAge,
+			want:   drupalupdate.Staleness{Package: "drupal/a", Latest: "3.0.5", LatestDate: releases[2].Date},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.CheckStaleness(tt.pkg, releases, tt.maxAge, now); got != tt.want {
+				t.Errorf("CheckStaleness() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+//spellchecker:words drupalupdate
+package drupalupdate
+
+//spellchecker:words strconv strings
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UpdateType classifies how big a jump a release is relative to the current constraint of a package.
+type UpdateType string
+
+const (
+	UpdateNone       UpdateType = "none"        // the release is not newer than the current constraint
+	UpdatePatch      UpdateType = "patch"       // the release is a newer patch (or build) of the current minor version
+	UpdateMinor      UpdateType = "minor"       // the release is a newer minor version of the current major version
+	UpdateMajor      UpdateType = "major"       // the release is a newer major version
+	UpdatePreRelease UpdateType = "pre-release" // the release is a newer pre-release (alpha, beta, RC or dev)
+)
+
+var errUpdateTypeInvalid = errors.New("invalid update type")
+
+// ParseUpdateType parses the name of an update type.
+func ParseUpdateType(s string) (UpdateType, error) {
+	switch update := UpdateType(strings.ToLower(s)); update {
+	case UpdateNone, UpdatePatch, UpdateMinor, UpdateMajor, UpdatePreRelease:
+		return update, nil
+	default:
+		return "", fmt.Errorf("%w: %q", errUpdateTypeInvalid, s)
+	}
+}
+
+// rank orders update types from the safest (0, none) to the riskiest (4, pre-release).
+func (u UpdateType) rank() int {
+	switch u {
+	case UpdatePatch:
+		return 1
+	case UpdateMinor:
+		return 2
+	case UpdateMajor:
+		return 3
+	case UpdatePreRelease:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ClassifyUpdate returns the type of update from the version base to the release version.
+// Releases that are not newer than base, including pre-releases of base itself, are [UpdateNone].
+// Pre-releases newer than base are always [UpdatePreRelease].
+func ClassifyUpdate(base, release Version) UpdateType {
+	switch {
+	case release.compareRelease(base) <= 0:
+		return UpdateNone
+	case release.Stability != StabilityStable:
+		return UpdatePreRelease
+	case seg(release.Major) != seg(base.Major):
+		return UpdateMajor
+	case seg(release.Minor) != seg(base.Minor):
+		return UpdateMinor
+	default:
+		return UpdatePatch
+	}
+}
+
+// base returns the newest lower bound of the alternatives of c, e.g. "10.0" for "^9.5 || ^10".
+// It returns a version with all segments set to -1 if there is no lower bound, e.g. for "*".
+func (c Constraint) base() Version {
+	base := ParseVersion("")
+	for _, group := range c.groups {
+		for _, term := range group {
+			if term.op != ">=" && term.op != ">" && term.op != "==" {
+				continue
+			}
+			if base.Major < 0 || term.version.compareSegments(base) > 0 {
+				base = term.version
+			}
+		}
+	}
+	return base
+}
+
+// MarkUpdateType sets the Update of each release relative to the lower bound of the current constraint.
+// It does nothing if the constraint cannot be parsed or has no lower bound (e.g. "*" or "dev-main").
+func MarkUpdateType(releases []Release, current string) {
+	c, err := ParseConstraint(current)
+	if err != nil {
+		return
+	}
+	base := c.base()
+	if base.Major < 0 {
+		return
+	}
+	if base.Stability == StabilityDev {
+		base.Stability = StabilityStable // the lower bound of a range is a dev version, so that it includes pre-releases
+	}
+	for i := range releases {
+		v := ParseVersion(releases[i].Version)
+		if v.Major < 0 || v.Minor == WildcardSegment {
+			continue
+		}
+		releases[i].Update = ClassifyUpdate(base, v)
+	}
+}
+
+// FilterUpdates returns the releases whose update type is at most as risky as limit,
+// in the order none, patch, minor, major and pre-release.
+// Releases without an update type are kept.
+func FilterUpdates(releases []Release, limit UpdateType) []Release {
+	filtered := make([]Release, 0, len(releases))
+	for _, release := range releases {
+		if release.Update != "" && release.Update.rank() > limit.rank() {
+			continue
+		}
+		filtered = append(filtered, release)
+	}
+	return filtered
+}
+
+// UpdateSummary counts the releases of a package per update type.
+type UpdateSummary struct {
+	Major, Minor, Patch, PreRelease int
+}
+
+// SummarizeUpdates counts the releases per update type, see [MarkUpdateType].
+func SummarizeUpdates(releases []Release) (summary UpdateSummary) {
+	for _, release := range releases {
+		switch release.Update {
+		case UpdateMajor:
+			summary.Major++
+		case UpdateMinor:
+			summary.Minor++
+		case UpdatePatch:
+			summary.Patch++
+		case UpdatePreRelease:
+			summary.PreRelease++
+		case UpdateNone:
+		}
+	}
+	return summary
+}
+
+// String formats the summary for display, e.g. "3 majors, 1 minor behind" or "up to date".
+func (s UpdateSummary) String() string {
+	var parts []string
+	for _, count := range []struct {
+		n    int
+		name string
+	}{
+		{s.Major, "major"},
+		{s.Minor, "minor"},
+		{s.Patch, "patch"},
+		{s.PreRelease, "pre-release"},
+	} {
+		switch {
+		case count.n == 1:
+			parts = append(parts, "1 "+count.name)
+		case count.n > 1 && count.name == "patch":
+			parts = append(parts, strconv.Itoa(count.n)+" patches")
+		case count.n > 1:
+			parts = append(parts, strconv.Itoa(count.n)+" "+count.name+"s")
+		}
+	}
+	if len(parts) == 0 {
+		return "up to date"
+	}
+	return strings.Join(parts, ", ") + " behind"
+}
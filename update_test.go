@@ -0,0 +1,147 @@
+//spellchecker:words drupalupdate
+package drupalupdate_test
+
+//spellchecker:words testing github composer drupal update drupalupdate
+import (
+	"testing"
+
+	drupalupdate "github.com/FAU-CDI/composer-drupal-update"
+)
+
+func TestClassifyUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		base    string
+		release string
+		want    drupalupdate.UpdateType
+	}{
+		{"5.0.3", "5.0.3", drupalupdate.UpdateNone},
+		{"5.0.3", "5.0.2", drupalupdate.UpdateNone},
+		{"5.0.3", "4.2.0", drupalupdate.UpdateNone},
+		{"5.0.3", "5.0.4", drupalupdate.UpdatePatch},
+		{"5.0.3", "5.0.3.1", drupalupdate.UpdatePatch},
+		{"5.0.3", "5.1.0", drupalupdate.UpdateMinor},
+		{"5.0.3", "6.0.0", drupalupdate.UpdateMajor},
+		{"5.0.3", "6.0.0-beta1", drupalupdate.UpdatePreRelease},
+		{"5.0.3", "5.0.4-rc1", drupalupdate.UpdatePreRelease},
+		{"5.0.3", "5.0.3-rc1", drupalupdate.UpdateNone},
+		{"5.0.3", "5.0.2-rc1", drupalupdate.UpdateNone},
+		{"1.0", "1.0.0-beta1", drupalupdate.UpdateNone},
+		{"1.0.0-beta1", "1.0.0-beta2", drupalupdate.UpdatePreRelease},
+		{"1.0.0-beta1", "1.0.0", drupalupdate.UpdatePatch},
+		{"3.16", "8.x-3.17", drupalupdate.UpdateMinor},
+		{"3.16", "8.x-4.0", drupalupdate.UpdateMajor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base+"_"+tt.release, func(t *testing.T) {
+			t.Parallel()
+			got := drupalupdate.ClassifyUpdate(drupalupdate.ParseVersion(tt.base), drupalupdate.ParseVersion(tt.release))
+			if got != tt.want {
+				t.Errorf("ClassifyUpdate(%q, %q) = %q, want %q", tt.base, tt.release, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkUpdateType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		current string
+		want    map[string]drupalupdate.UpdateType
+	}{
+		{"^10.3", map[string]drupalupdate.UpdateType{
+			"11.1.0":        drupalupdate.UpdateMajor,
+			"10.4.3":        drupalupdate.UpdateMinor,
+			"10.3.2":        drupalupdate.UpdatePatch,
+			"10.3.0":        drupalupdate.UpdateNone,
+			"10.3.0-beta1":  drupalupdate.UpdateNone,
+			"12.0.0-alpha1": drupalupdate.UpdatePreRelease,
+		}},
+		{"^9.5 || ^10.3", map[string]drupalupdate.UpdateType{
+			"11.1.0": drupalupdate.UpdateMajor,
+			"10.4.3": drupalupdate.UpdateMinor,
+			"9.5.11": drupalupdate.UpdateNone,
+		}},
+		{"~10.3.1", map[string]drupalupdate.UpdateType{
+			"10.3.2": drupalupdate.UpdatePatch,
+		}},
+		{"*", map[string]drupalupdate.UpdateType{
+			"11.1.0": "",
+		}},
+		{"not a constraint", map[string]drupalupdate.UpdateType{
+			"11.1.0": "",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			t.Parallel()
+			releases := make([]drupalupdate.Release, 0, len(tt.want))
+			for version := range tt.want {
+				releases = append(releases, drupalupdate.Release{Version: version})
+			}
+			drupalupdate.MarkUpdateType(releases, tt.current)
+			for _, release := range releases {
+				if release.Update != tt.want[release.Version] {
+					t.Errorf("release %s: expected %q, got %q", release.Version, tt.want[release.Version], release.Update)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterUpdates(t *testing.T) {
+	t.Parallel()
+	releases := []drupalupdate.Release{
+		{Version: "12.0.0-alpha1", Update: drupalupdate.UpdatePreRelease},
+		{Version: "11.1.0", Update: drupalupdate.UpdateMajor},
+		{Version: "10.4.3", Update: drupalupdate.UpdateMinor},
+		{Version: "10.3.2", Update: drupalupdate.UpdatePatch},
+		{Version: "dev-main"},
+	}
+
+	got := drupalupdate.FilterUpdates(releases, drupalupdate.UpdateMinor)
+	want := []string{"10.4.3", "10.3.2", "dev-main"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d releases, got %d", len(want), len(got))
+	}
+	for i, release := range got {
+		if release.Version != want[i] {
+			t.Errorf("release %d: expected %s, got %s", i, want[i], release.Version)
+		}
+	}
+}
+
+func TestSummarizeUpdates(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		updates []drupalupdate.UpdateType
+		want    string
+	}{
+		{nil, "up to date"},
+		{[]drupalupdate.UpdateType{drupalupdate.UpdateNone}, "up to date"},
+		{[]drupalupdate.UpdateType{drupalupdate.UpdateMajor, drupalupdate.UpdateMajor, drupalupdate.UpdateMajor, drupalupdate.UpdateMinor}, "3 majors, 1 minor behind"},
+		{[]drupalupdate.UpdateType{drupalupdate.UpdatePatch, drupalupdate.UpdatePatch, drupalupdate.UpdatePreRelease}, "2 patches, 1 pre-release behind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			releases := make([]drupalupdate.Release, len(tt.updates))
+			for i, update := range tt.updates {
+				releases[i].Update = update
+			}
+			if got := drupalupdate.SummarizeUpdates(releases).String(); got != tt.want {
+				t.Errorf("SummarizeUpdates().String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUpdateType(t *testing.T) {
+	t.Parallel()
+	if got, err := drupalupdate.ParseUpdateType("Minor"); err != nil || got != drupalupdate.UpdateMinor {
+		t.Errorf("ParseUpdateType(%q) = %q, %v, want %q", "Minor", got, err, drupalupdate.UpdateMinor)
+	}
+	if _, err := drupalupdate.ParseUpdateType("huge"); err == nil {
+		t.Errorf("ParseUpdateType(%q) expected error", "huge")
+	}
+}
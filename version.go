@@ -9,55 +9,99 @@ import (
 )
 
 // Version holds parsed segments of a version string (e.g. from drupal.org or Packagist).
+//
+// Versions follow the composer version model: up to four numeric segments,
+// an optional stability with a number (e.g. "RC2"), and development versions
+// such as "1.x-dev" (a numbered branch) or "dev-main" (a named branch).
 type Version struct {
 	Prefix    string // e.g. "8.x", "12.x", ... or ""
-	Stability string // "", "RC", "beta", "alpha"
+	Stability string // "", "RC", "beta", "alpha" or "dev"
+	Branch    string // name of a development branch, e.g. "main" for "dev-main", or ""
 
 	// Regular version segments, -1 indicates missing segment.
-	Major, Minor, Patch int
+	// Wildcard segments of numbered branches (e.g. "1.x-dev") are set to [WildcardSegment].
+	Major, Minor, Patch, Build int
+
+	// StabilityNumber is the number following the stability, e.g. 2 for "beta2", or -1 if missing.
+	StabilityNumber int
 }
 
+// Stability levels of a version, from most to least stable.
+const (
+	StabilityStable = ""      // a stable release
+	StabilityRC     = "RC"    // a release candidate
+	StabilityBeta   = "beta"  // a beta release
+	StabilityAlpha  = "alpha" // an alpha release
+	StabilityDev    = "dev"   // a development version
+)
+
+// WildcardSegment is the value of wildcard segments of numbered branches like "1.x-dev".
+// Like in composer, it orders numbered branches after all releases of the branch.
+const WildcardSegment = 9999999
+
 var (
-	versionRegex      = regexp.MustCompile(`^((\d+\.x)-)?((\d+)(\.\d+){0,2})(-([aA-zZ]+).*)?$`) // regular expression used to parse a version
-	stabilityPrefixes = []string{"RC", "beta", "alpha"}                                         // version strings
+	// versionRegex parses a version, e.g. "8.x-1.0-rc17", "v2.1.0-beta.2", "1.2.3.4" or "1.x-dev".
+	versionRegex = regexp.MustCompile(`(?i)^v?(?:(\d+\.x)-)?(\d+)(?:\.(\d+|[x*]))?(?:\.(\d+|[x*]))?(?:\.(\d+|[x*]))?(?:[-._]?(alpha|beta|rc|dev|a|b)\.?(\d+)?)?(?:[-+.].*)?$`)
+
+	// branchRegex parses a named development branch, e.g. "dev-main".
+	branchRegex = regexp.MustCompile(`^dev-(.+)$`)
+
+	stabilityPrefixes = []string{StabilityRC, StabilityBeta, StabilityAlpha} // version strings
 )
 
 // ParseVersion parses a raw version string into a Version.
-// Handles optional "8.x-" prefix and stability suffixes (-rc*, -beta*, -alpha*).
-// Numeric parts are parsed segment by segment; non-numeric segments are treated as 0.
+// Handles optional "v" and "8.x-" prefixes, up to four segments, wildcard segments of numbered branches,
+// stability suffixes with an optional number (-rc2, -beta.1, -alpha3, -dev) and named branches ("dev-main").
+// Versions that cannot be parsed have all segments set to -1.
 func ParseVersion(s string) (v Version) {
 	// default all the versions to -1
-	v.Major, v.Minor, v.Patch = -1, -1, -1
+	v.Major, v.Minor, v.Patch, v.Build = -1, -1, -1, -1
+	v.StabilityNumber = -1
 
-	matches := versionRegex.FindAllStringSubmatch(s, 1)
-	if len(matches) == 0 {
+	if match := branchRegex.FindStringSubmatch(s); match != nil {
+		v.Branch = match[1]
+		v.Stability = StabilityDev
 		return v
 	}
 
-	match := matches[0]
-	if len(match) != 8 {
-		panic("never reached: match must have length 8")
+	match := versionRegex.FindStringSubmatch(s)
+	if match == nil {
+		return v
 	}
 
-	v.Prefix = match[2]
+	v.Prefix = strings.ToLower(match[1])
 
-	digits := strings.SplitN(match[3], ".", 3)
-	if len(digits) > 0 {
-		v.Major = parseLeadingInt(digits[0])
+	wildcard := false
+	for i, segment := range []*int{&v.Major, &v.Minor, &v.Patch, &v.Build} {
+		digits := match[i+2]
+		switch {
+		case wildcard || digits == "x" || digits == "X" || digits == "*":
+			wildcard = true
+			*segment = WildcardSegment
+		case digits != "":
+			*segment = parseLeadingInt(digits)
+		}
 	}
-	if len(digits) > 1 {
-		v.Minor = parseLeadingInt(digits[1])
+
+	v.Stability = normalizeStability(match[6])
+	if match[7] != "" {
+		v.StabilityNumber = parseLeadingInt(match[7])
 	}
-	if len(digits) > 2 {
-		v.Patch = parseLeadingInt(digits[2])
+	if wildcard {
+		// wildcard segments only occur in numbered branches
+		v.Stability = StabilityDev
 	}
 
-	v.Stability = parseStability(match[7])
-
 	return v
 }
 
 func parseStability(s string) string {
+	switch strings.ToLower(s) {
+	case "a":
+		return StabilityAlpha
+	case "b":
+		return StabilityBeta
+	}
 	for _, prefix := range stabilityPrefixes {
 		if !strings.EqualFold(s, prefix) {
 			continue
@@ -67,14 +111,43 @@ func parseStability(s string) string {
 	return ""
 }
 
+// normalizeStability normalizes a stability name (e.g. from a "@beta" flag) to one of the Stability constants.
+// Unknown names and "stable" are normalized to [StabilityStable].
+func normalizeStability(s string) string {
+	if strings.EqualFold(s, StabilityDev) {
+		return StabilityDev
+	}
+	return parseStability(s)
+}
+
+// stabilityRank orders stabilities from least (0, dev) to most (4, stable) stable.
+func stabilityRank(stability string) int {
+	switch stability {
+	case StabilityDev:
+		return 0
+	case StabilityAlpha:
+		return 1
+	case StabilityBeta:
+		return 2
+	case StabilityRC:
+		return 3
+	default:
+		return 4
+	}
+}
+
 // VersionPin returns the composer version constraint for this version.
-// Drops patch, prepends "^", and appends @RC/@beta/@alpha when applicable.
+// Drops patch, prepends "^", and appends @RC/@beta/@alpha/@dev when applicable.
+// Named branches are pinned as is, e.g. "dev-main".
 //
 //	Major=5, Minor=0, Patch=3 → "^5.0"
 //	Stability="RC" → "^1.0@RC"
 func (v Version) VersionPin() string {
+	if v.Branch != "" {
+		return "dev-" + v.Branch
+	}
 	pin := "^"
-	if v.Minor >= 0 {
+	if v.Minor >= 0 && v.Minor != WildcardSegment {
 		pin += strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
 	} else {
 		pin += strconv.Itoa(v.Major)
@@ -85,19 +158,116 @@ func (v Version) VersionPin() string {
 	return pin
 }
 
+// ReleaseBranch returns the name of the development branch a version is released from,
+// following the branch names of drupal.org.
+// It returns "" if v could not be parsed.
+//
+//	"4.0.2" → "4.0.x", "8.x-2.5" → "8.x-2.x", "13.x-dev" → "13.x", "dev-main" → "main"
+func (v Version) ReleaseBranch() string {
+	switch {
+	case v.Branch != "":
+		return v.Branch
+	case v.Major < 0:
+		return ""
+	case v.Prefix != "":
+		return v.Prefix + "-" + strconv.Itoa(v.Major) + ".x"
+	case v.Minor < 0 || v.Minor == WildcardSegment:
+		return strconv.Itoa(v.Major) + ".x"
+	default:
+		return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + ".x"
+	}
+}
+
+// Normalized returns the normalized form of v, like the "version_normalized" of Packagist.
+// The prefix is dropped, missing segments are set to 0 and the stability is appended,
+// e.g. "8.x-1.0-rc2" → "1.0.0.0-RC2", "1.x-dev" → "1.9999999.9999999.9999999-dev" and "dev-main" → "dev-main".
+// It returns "" if v could not be parsed.
+func (v Version) Normalized() string {
+	if v.Branch != "" {
+		return "dev-" + v.Branch
+	}
+	if v.Major < 0 {
+		return ""
+	}
+
+	segments := make([]string, 4)
+	for i, segment := range []int{v.Major, v.Minor, v.Patch, v.Build} {
+		segments[i] = strconv.Itoa(seg(segment))
+	}
+	normalized := strings.Join(segments, ".")
+	if v.Stability != StabilityStable {
+		normalized += "-" + v.Stability
+		if v.StabilityNumber >= 0 && v.Stability != StabilityDev {
+			normalized += strconv.Itoa(v.StabilityNumber)
+		}
+	}
+	return normalized
+}
+
 // Compare returns a comparison result: >0 if v > other, <0 if v < other, 0 if equal.
 // Used for sorting (e.g. descending = other.Compare(v)).
-// Missing segment (-1) is treated as 0 for comparison.
+//
+// Versions are ordered by their segments, where a missing segment (-1) is treated as 0,
+// then by stability (dev < alpha < beta < RC < stable) and stability number.
+// Remaining ties are broken by the drupal core prefix, so that "2.0" < "7.x-2.0" < "8.x-2.0" holds.
+// Named branches are older than all numbered versions, and ordered by name among themselves.
 func (v Version) Compare(other Version) int {
-	ma, mb := seg(v.Major), seg(other.Major)
-	if ma != mb {
-		return ma - mb
+	if v.Branch != "" || other.Branch != "" {
+		switch {
+		case v.Branch == "":
+			return 1
+		case other.Branch == "":
+			return -1
+		default:
+			return strings.Compare(v.Branch, other.Branch)
+		}
+	}
+
+	if cmp := v.compareRelease(other); cmp != 0 {
+		return cmp
 	}
-	miA, miB := seg(v.Minor), seg(other.Minor)
-	if miA != miB {
-		return miA - miB
+	return prefixCore(v.Prefix) - prefixCore(other.Prefix)
+}
+
+// compareRelease compares the segments and stability of numbered versions, ignoring the prefix.
+func (v Version) compareRelease(other Version) int {
+	if cmp := v.compareSegments(other); cmp != 0 {
+		return cmp
+	}
+	pairs := [][2]int{
+		{stabilityRank(v.Stability), stabilityRank(other.Stability)},
+		{v.StabilityNumber, other.StabilityNumber},
+	}
+	for _, pair := range pairs {
+		if a, b := seg(pair[0]), seg(pair[1]); a != b {
+			return a - b
+		}
+	}
+	return 0
+}
+
+// compareSegments compares the numeric segments of numbered versions, ignoring prefix and stability.
+func (v Version) compareSegments(other Version) int {
+	pairs := [][2]int{
+		{v.Major, other.Major},
+		{v.Minor, other.Minor},
+		{v.Patch, other.Patch},
+		{v.Build, other.Build},
+	}
+	for _, pair := range pairs {
+		if a, b := seg(pair[0]), seg(pair[1]); a != b {
+			return a - b
+		}
+	}
+	return 0
+}
+
+// prefixCore returns the drupal core major version of a prefix like "8.x", or 0 if there is none.
+func prefixCore(prefix string) int {
+	if prefix == "" {
+		return 0
 	}
-	return seg(v.Patch) - seg(other.Patch)
+	return parseLeadingInt(prefix)
 }
 
 func seg(n int) int {
@@ -27,6 +27,10 @@ func TestParseVersion(t *testing.T) {
 		{"1.0.0-alpha1", "", 1, 0, 0, "alpha"},
 		{"12.x-1.0.3-beta5", "12.x", 1, 0, 3, "beta"},
 		{"42", "", 42, -1, -1, ""},
+		{"1.2.3.4", "", 1, 2, 3, ""},
+		{"v2.1.0-beta.2", "", 2, 1, 0, "beta"},
+		{"1.x-dev", "", 1, drupalupdate.WildcardSegment, drupalupdate.WildcardSegment, "dev"},
+		{"dev-main", "", -1, -1, -1, "dev"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
@@ -78,6 +82,8 @@ func TestVersion_VersionPin(t *testing.T) {
 		{"1.0.0-alpha1", "^1.0@alpha"},
 		{"8.x-2.0-alpha5", "^2.0@alpha"},
 		{"42", "^42"},
+		{"1.x-dev", "^1@dev"},
+		{"dev-main", "dev-main"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
@@ -106,6 +112,21 @@ func TestVersion_Compare(t *testing.T) {
 		{"8.x-1.5", "3.0.5", -1},
 		{"3.0.5", "3.0.5", 0},
 		{"42", "11.1.0", 1},
+		{"1.0.0", "1.0.0-rc1", 1},
+		{"1.0.0-rc1", "1.0.0-beta2", 1},
+		{"1.0.0-beta2", "1.0.0-alpha3", 1},
+		{"1.0.0-alpha3", "1.0.0-dev", 1},
+		{"1.0.0-rc1", "1.0.0-rc2", -1},
+		{"1.0.0-RC2", "1.0.0-rc2", 0},
+		{"1.0.0", "1.0", 0},
+		{"1.0.0.1", "1.0.0", 1},
+		{"1.0.0.1", "1.0.1", -1},
+		{"1.x-dev", "1.99.0", 1},
+		{"1.x-dev", "2.0.0-alpha1", -1},
+		{"dev-main", "0.0.1", -1},
+		{"dev-feature", "dev-main", -1},
+		{"8.x-2.0", "2.0", 1},
+		{"7.x-2.0", "8.x-2.0", -1},
 	}
 	for _, tt := range tests {
 		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
@@ -120,3 +141,89 @@ func TestVersion_Compare(t *testing.T) {
 		})
 	}
 }
+
+func TestVersion_Normalized(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"5.0.3", "5.0.3.0"},
+		{"v5.0.3", "5.0.3.0"},
+		{"8.x-3.16", "3.16.0.0"},
+		{"1.2.3.4", "1.2.3.4"},
+		{"13.0.0-rc1", "13.0.0.0-RC1"},
+		{"8.x-1.0-rc17", "1.0.0.0-RC17"},
+		{"2.0.0-beta.2", "2.0.0.0-beta2"},
+		{"1.0.0-alpha", "1.0.0.0-alpha"},
+		{"1.0.0-b3", "1.0.0.0-beta3"},
+		{"1.x-dev", "1.9999999.9999999.9999999-dev"},
+		{"2.1.x-dev", "2.1.9999999.9999999-dev"},
+		{"dev-main", "dev-main"},
+		{"not a version", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got := drupalupdate.ParseVersion(tt.input).Normalized()
+			if got != tt.want {
+				t.Errorf("ParseVersion(%q).Normalized() = %q, want %q", tt.input, got, tt.want)
+			}
+			if got == "" {
+				return
+			}
+			if again := drupalupdate.ParseVersion(got).Normalized(); again != got {
+				t.Errorf("ParseVersion(%q).Normalized() = %q, want round-trip %q", got, again, got)
+			}
+		})
+	}
+}
+
+func TestVersion_StabilityNumber(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"1.0.0-rc2", 2},
+		{"8.x-1.0-rc17", 17},
+		{"2.0.0-beta.3", 3},
+		{"1.0.0-alpha", -1},
+		{"1.0.0", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.ParseVersion(tt.input).StabilityNumber; got != tt.want {
+				t.Errorf("ParseVersion(%q).StabilityNumber = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_ReleaseBranch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"4.0.2", "4.0.x"},
+		{"v13.0.1", "13.0.x"},
+		{"2.0.0-beta1", "2.0.x"},
+		{"8.x-2.5", "8.x-2.x"},
+		{"8.x-1.x-dev", "8.x-1.x"},
+		{"4.0.x-dev", "4.0.x"},
+		{"13.x-dev", "13.x"},
+		{"5", "5.x"},
+		{"dev-main", "main"},
+		{"not a version", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			if got := drupalupdate.ParseVersion(tt.input).ReleaseBranch(); got != tt.want {
+				t.Errorf("ParseVersion(%q).ReleaseBranch() = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}